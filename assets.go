@@ -0,0 +1,183 @@
+package jumble
+
+import (
+	"fmt"
+	"image"
+	"net/http"
+	"os"
+	"path"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/rakyll/statik/fs"
+)
+
+// assetPack is a named collection of images
+// stored in a directory of a file system.
+type assetPack struct {
+	fs  http.FileSystem
+	dir string
+}
+
+var (
+	assetsMu   sync.RWMutex
+	assetPacks = map[string]assetPack{}
+
+	builtinOnce sync.Once
+	builtinFS   http.FileSystem
+	builtinErr  error
+)
+
+// builtinPacks lists the packs embedded in the statik file system.
+var builtinPacks = []string{"aws", "azure", "google"}
+
+// RegisterAssetPack registers a file system as the named asset pack.
+// Images are addressed as `assets://<name>_<kind>` and looked up
+// as `<name>_<kind>.png` or `<kind>.png` in the file system root.
+func RegisterAssetPack(name string, fs http.FileSystem) error {
+	if strings.TrimSpace(name) == "" {
+		return fmt.Errorf("asset pack name can't be empty")
+	}
+
+	if fs == nil {
+		return fmt.Errorf("asset pack '%s' has no file system", name)
+	}
+
+	assetsMu.Lock()
+	assetPacks[name] = assetPack{fs: fs, dir: "/"}
+	assetsMu.Unlock()
+
+	return nil
+}
+
+// RegisterAssetPackPath registers a directory
+// or a zip archive as the named asset pack.
+func RegisterAssetPackPath(name, filename string) error {
+	fi, err := os.Stat(filename)
+	if err != nil {
+		return err
+	}
+
+	if fi.IsDir() {
+		return RegisterAssetPack(name, http.Dir(filename))
+	}
+
+	zfs, err := newZipFS(filename)
+	if err != nil {
+		return err
+	}
+
+	return RegisterAssetPack(name, zfs)
+}
+
+// AssetPacks returns the names of all the available asset packs.
+func AssetPacks() []string {
+	assetsMu.RLock()
+	defer assetsMu.RUnlock()
+
+	res := append([]string{}, builtinPacks...)
+	for name := range assetPacks {
+		if !isBuiltinPack(name) {
+			res = append(res, name)
+		}
+	}
+	sort.Strings(res)
+
+	return res
+}
+
+// LoadFromAssets load an image from the registered asset packs.
+func LoadFromAssets(uri string) (image.Image, error) {
+	file, err := OpenAsset(uri)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	im, _, err := image.Decode(file)
+	return im, err
+}
+
+// OpenAsset opens the file addressed by an `assets://` URI.
+func OpenAsset(uri string) (http.File, error) {
+	filename := strings.TrimPrefix(uri, "assets://")
+	if path.Ext(filename) == "" {
+		filename = filename + ".png"
+	}
+
+	for _, name := range lookupOrder(filename) {
+		packs, err := packsByName(name)
+		if err != nil {
+			return nil, err
+		}
+
+		candidates := []string{
+			filename,
+			strings.TrimPrefix(filename, name+"_"),
+		}
+
+		for _, pack := range packs {
+			for _, fn := range candidates {
+				file, err := pack.fs.Open(path.Join(pack.dir, fn))
+				if err == nil {
+					return file, nil
+				}
+			}
+		}
+	}
+
+	return nil, fmt.Errorf("unknow asset: %s", filename)
+}
+
+// lookupOrder returns the names of the packs that can hold
+// the specified file, longest (most specific) first.
+func lookupOrder(filename string) []string {
+	var res []string
+	for _, name := range AssetPacks() {
+		if strings.HasPrefix(filename, name+"_") {
+			res = append(res, name)
+		}
+	}
+
+	sort.SliceStable(res, func(i, j int) bool {
+		return len(res[i]) > len(res[j])
+	})
+
+	return res
+}
+
+// packsByName returns the named asset packs; user defined
+// packs take precedence over the embedded ones, which are
+// still searched for the images missing in the user pack.
+func packsByName(name string) ([]assetPack, error) {
+	var res []assetPack
+
+	assetsMu.RLock()
+	pack, ok := assetPacks[name]
+	assetsMu.RUnlock()
+	if ok {
+		res = append(res, pack)
+		if !isBuiltinPack(name) {
+			return res, nil
+		}
+	}
+
+	builtinOnce.Do(func() {
+		builtinFS, builtinErr = fs.New()
+	})
+	if builtinErr != nil {
+		return nil, builtinErr
+	}
+
+	return append(res, assetPack{fs: builtinFS, dir: path.Join("/", name)}), nil
+}
+
+func isBuiltinPack(name string) bool {
+	for _, el := range builtinPacks {
+		if el == name {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,132 @@
+package jumble
+
+import (
+	"archive/zip"
+	"image"
+	"image/png"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLoadFromBuiltinAssets(t *testing.T) {
+	im, err := LoadFromAssets("assets://aws_lambda")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.False(t, im.Bounds().Empty())
+}
+
+func TestAssetPackDir(t *testing.T) {
+	dir, err := ioutil.TempDir("", "jumble")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	writePNG(t, filepath.Join(dir, "payment_service.png"), 3, 2)
+
+	if err := RegisterAssetPackPath("acme", dir); err != nil {
+		t.Fatal(err)
+	}
+
+	im, err := LoadImage("assets://acme_payment_service")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, image.Rect(0, 0, 3, 2), im.Bounds())
+	assert.Contains(t, AssetPacks(), "acme")
+
+	_, err = LoadImage("assets://acme_missing")
+	assert.Error(t, err)
+}
+
+func TestAssetPackBuiltinFallback(t *testing.T) {
+	dir, err := ioutil.TempDir("", "jumble")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	writePNG(t, filepath.Join(dir, "custom.png"), 3, 2)
+
+	if err := RegisterAssetPackPath("aws", dir); err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		assetsMu.Lock()
+		delete(assetPacks, "aws")
+		assetsMu.Unlock()
+	}()
+
+	im, err := LoadImage("assets://aws_custom")
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, image.Rect(0, 0, 3, 2), im.Bounds())
+
+	im, err = LoadImage("assets://aws_lambda")
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.False(t, im.Bounds().Empty())
+}
+
+func TestAssetPackZip(t *testing.T) {
+	dir, err := ioutil.TempDir("", "jumble")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	src := filepath.Join(dir, "icon.png")
+	writePNG(t, src, 4, 4)
+
+	fout, err := os.Create(filepath.Join(dir, "icons.zip"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	zw := zip.NewWriter(fout)
+	w, err := zw.Create("zippy_icon.png")
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := ioutil.ReadFile(src)
+	if err != nil {
+		t.Fatal(err)
+	}
+	w.Write(data)
+	zw.Close()
+	fout.Close()
+
+	if err := RegisterAssetPackPath("zippy", fout.Name()); err != nil {
+		t.Fatal(err)
+	}
+
+	im, err := LoadImage("assets://zippy_icon")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, image.Rect(0, 0, 4, 4), im.Bounds())
+}
+
+func writePNG(t *testing.T, filename string, w, h int) {
+	t.Helper()
+
+	fout, err := os.Create(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer fout.Close()
+
+	if err := png.Encode(fout, image.NewRGBA(image.Rect(0, 0, w, h))); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,79 @@
+package jumble
+
+import (
+	"archive/zip"
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path"
+)
+
+// zipFS is a read only http.FileSystem
+// backed by the content of a zip archive.
+type zipFS struct {
+	files map[string]*zip.File
+}
+
+// newZipFS opens the specified zip archive.
+func newZipFS(filename string) (http.FileSystem, error) {
+	data, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+
+	zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return nil, fmt.Errorf("reading zip archive '%s': %w", filename, err)
+	}
+
+	res := &zipFS{files: map[string]*zip.File{}}
+	for _, zf := range zr.File {
+		if zf.FileInfo().IsDir() {
+			continue
+		}
+		res.files[path.Join("/", zf.Name)] = zf
+	}
+
+	return res, nil
+}
+
+// Open implements the http.FileSystem interface.
+func (z *zipFS) Open(name string) (http.File, error) {
+	zf, ok := z.files[path.Join("/", name)]
+	if !ok {
+		return nil, &os.PathError{Op: "open", Path: name, Err: os.ErrNotExist}
+	}
+
+	rc, err := zf.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer rc.Close()
+
+	data, err := ioutil.ReadAll(rc)
+	if err != nil {
+		return nil, err
+	}
+
+	return &zipFile{Reader: bytes.NewReader(data), info: zf.FileInfo()}, nil
+}
+
+// zipFile is an uncompressed zip archive entry.
+type zipFile struct {
+	*bytes.Reader
+	info os.FileInfo
+}
+
+func (f *zipFile) Close() error {
+	return nil
+}
+
+func (f *zipFile) Readdir(count int) ([]os.FileInfo, error) {
+	return nil, fmt.Errorf("%s is not a directory", f.info.Name())
+}
+
+func (f *zipFile) Stat() (os.FileInfo, error) {
+	return f.info, nil
+}
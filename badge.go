@@ -0,0 +1,156 @@
+package jumble
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/fogleman/gg"
+)
+
+// Icon overlays.
+const (
+	// OverlayStrike draws a diagonal line across the icon.
+	OverlayStrike = "strike"
+	// OverlayCross draws an X across the icon.
+	OverlayCross = "cross"
+	// OverlayLock draws a padlock over the icon.
+	OverlayLock = "lock"
+)
+
+// Badge is a small marker pinned to a corner of an icon.
+type Badge struct {
+	// Corner is the badge position: top_right (default),
+	// top_left, bottom_right or bottom_left.
+	Corner string
+	// Text is the badge content (a count or a short text);
+	// a colored dot is drawn when empty.
+	Text      string
+	Color     string
+	TextColor string
+	// Size is the badge height relative to the cell size.
+	Size float64
+}
+
+// plot draws the badge in the cell with the specified center.
+func (b *Badge) plot(g *Grid, center gg.Point) error {
+	corner := b.Corner
+	if corner == "" {
+		corner = "top_right"
+	}
+
+	switch corner {
+	case "top_right", "top_left", "bottom_right", "bottom_left":
+	default:
+		return fmt.Errorf("unknown badge corner: %s", corner)
+	}
+
+	ax, ay, _ := anchorPoint(corner)
+
+	size := b.Size
+	if size <= 0 {
+		size = 0.3
+	}
+
+	color := b.Color
+	if color == "" {
+		color = "#e74c3c"
+	}
+
+	textColor := b.TextColor
+	if textColor == "" {
+		textColor = "#ffffff"
+	}
+
+	cs := g.CellSize()
+	h := math.Min(size, 1) * cs
+
+	dc := g.Context()
+	dc.Push()
+	defer dc.Pop()
+
+	if b.Text == "" {
+		x := center.X + (ax-0.5)*(cs-h)
+		y := center.Y + (ay-0.5)*(cs-h)
+
+		dc.SetHexColor(color)
+		dc.DrawCircle(x, y, 0.5*h)
+		dc.Fill()
+		return nil
+	}
+
+	face, err := g.fontFace("", true, false, 0.7*h)
+	if err != nil {
+		return err
+	}
+	dc.SetFontFace(face)
+
+	tw, _ := dc.MeasureString(b.Text)
+	w := math.Min(math.Max(h, tw+0.6*h), cs)
+
+	x := center.X + (ax-0.5)*(cs-w)
+	y := center.Y + (ay-0.5)*(cs-h)
+
+	dc.SetHexColor(color)
+	dc.DrawRoundedRectangle(x-0.5*w, y-0.5*h, w, h, 0.5*h)
+	dc.Fill()
+
+	dc.SetHexColor(textColor)
+	dc.DrawStringAnchored(b.Text, x, y, 0.5, 0.35)
+
+	return nil
+}
+
+// plotOverlay draws the named overlay in the cell with the specified center.
+func plotOverlay(g *Grid, center gg.Point, name, color string) error {
+	cs := g.CellSize()
+
+	dc := g.Context()
+	dc.Push()
+	defer dc.Pop()
+
+	dc.SetLineCapRound()
+	dc.SetLineWidth(0.06 * cs)
+
+	switch name {
+	case OverlayStrike:
+		if color == "" {
+			color = "#e74c3c"
+		}
+		dc.SetHexColor(color)
+		dc.DrawLine(center.X-0.4*cs, center.Y+0.4*cs, center.X+0.4*cs, center.Y-0.4*cs)
+		dc.Stroke()
+
+	case OverlayCross:
+		if color == "" {
+			color = "#e74c3c"
+		}
+		dc.SetHexColor(color)
+		dc.DrawLine(center.X-0.4*cs, center.Y+0.4*cs, center.X+0.4*cs, center.Y-0.4*cs)
+		dc.DrawLine(center.X-0.4*cs, center.Y-0.4*cs, center.X+0.4*cs, center.Y+0.4*cs)
+		dc.Stroke()
+
+	case OverlayLock:
+		if color == "" {
+			color = "#000000cc"
+		}
+		w, h := 0.36*cs, 0.26*cs
+		x, y := center.X-0.5*w, center.Y-0.1*cs
+
+		dc.SetHexColor(color)
+		dc.SetLineWidth(0.05 * cs)
+		dc.DrawArc(center.X, y, 0.12*cs, math.Pi, 2*math.Pi)
+		dc.MoveTo(center.X-0.12*cs, y)
+		dc.LineTo(center.X-0.12*cs, y+0.02*cs)
+		dc.MoveTo(center.X+0.12*cs, y)
+		dc.LineTo(center.X+0.12*cs, y+0.02*cs)
+		dc.Stroke()
+
+		dc.DrawRoundedRectangle(x, y, w, h, 0.04*cs)
+		dc.Fill()
+
+	default:
+		return fmt.Errorf("unknown overlay: %s", name)
+	}
+
+	return nil
+}
@@ -0,0 +1,209 @@
+// Package cache implements a content-addressed on-disk
+// store for remote resources (icons and configurations).
+package cache
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// ErrNotCached is returned in offline mode
+// when a resource is not in the cache.
+var ErrNotCached = errors.New("resource not available offline")
+
+// Cache stores the remote resources on disk.
+//
+// The content is saved once under the SHA-256 of its bytes,
+// and each URI is indexed with its validators (ETag and
+// Last-Modified) so that expired entries can be revalidated.
+// Without a directory nothing is cached.
+type Cache struct {
+	dir     string
+	ttl     time.Duration
+	offline bool
+}
+
+// Entry is the index record of a cached URI.
+type Entry struct {
+	URI          string    `json:"uri"`
+	Blob         string    `json:"blob"`
+	ETag         string    `json:"etag,omitempty"`
+	LastModified string    `json:"last_modified,omitempty"`
+	FetchedAt    time.Time `json:"fetched_at"`
+}
+
+// New creates a cache rooted at the specified directory.
+func New(dir string, opts ...func(*Cache)) *Cache {
+	res := Cache{
+		dir: dir,
+		ttl: 24 * time.Hour,
+	}
+
+	for _, opt := range opts {
+		opt(&res)
+	}
+
+	return &res
+}
+
+// TTL sets how long a cached resource is
+// served without revalidating it.
+func TTL(val time.Duration) func(*Cache) {
+	return func(c *Cache) {
+		c.ttl = val
+	}
+}
+
+// Offline serves the resources only from the cache.
+func Offline(val bool) func(*Cache) {
+	return func(c *Cache) {
+		c.offline = val
+	}
+}
+
+// DefaultDir returns the default cache directory
+// under the user cache directory.
+func DefaultDir() (string, error) {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return "", err
+	}
+
+	return filepath.Join(dir, "jumble"), nil
+}
+
+// Dir returns the cache root directory.
+func (c *Cache) Dir() string {
+	return c.dir
+}
+
+// Fresh reports if the entry can be served without revalidation.
+func (c *Cache) Fresh(ent Entry) bool {
+	return c.offline || time.Since(ent.FetchedAt) < c.ttl
+}
+
+// IsOffline reports if resources must be served only from the cache.
+func (c *Cache) IsOffline() bool {
+	return c.offline
+}
+
+// Store saves the content of the specified URI with its validators.
+func (c *Cache) Store(uri string, data []byte, etag, lastModified string) error {
+	if c.dir == "" {
+		return nil
+	}
+
+	blob, err := c.saveBlob(data)
+	if err != nil {
+		return err
+	}
+
+	return c.saveEntry(Entry{
+		URI:          uri,
+		Blob:         blob,
+		ETag:         etag,
+		LastModified: lastModified,
+		FetchedAt:    time.Now(),
+	})
+}
+
+// Touch marks a revalidated entry as fresh.
+func (c *Cache) Touch(ent Entry) error {
+	ent.FetchedAt = time.Now()
+	return c.saveEntry(ent)
+}
+
+// Clean removes all the cached resources.
+func (c *Cache) Clean() error {
+	if c.dir == "" {
+		return nil
+	}
+
+	return os.RemoveAll(c.dir)
+}
+
+// Lookup returns the index entry and the content of the specified URI.
+func (c *Cache) Lookup(uri string) (Entry, []byte, bool) {
+	if c.dir == "" {
+		return Entry{}, nil, false
+	}
+
+	src, err := ioutil.ReadFile(c.entryPath(uri))
+	if err != nil {
+		return Entry{}, nil, false
+	}
+
+	var ent Entry
+	if err := json.Unmarshal(src, &ent); err != nil || ent.URI != uri {
+		return Entry{}, nil, false
+	}
+
+	data, err := ioutil.ReadFile(filepath.Join(c.dir, "blobs", ent.Blob))
+	if err != nil {
+		return Entry{}, nil, false
+	}
+
+	return ent, data, true
+}
+
+// saveBlob stores the data under its SHA-256 and returns the digest.
+func (c *Cache) saveBlob(data []byte) (string, error) {
+	sum := sha256.Sum256(data)
+	blob := hex.EncodeToString(sum[:])
+
+	fn := filepath.Join(c.dir, "blobs", blob)
+	if _, err := os.Stat(fn); err == nil {
+		return blob, nil
+	}
+
+	return blob, writeFile(fn, data)
+}
+
+// saveEntry stores the index entry of a URI.
+func (c *Cache) saveEntry(ent Entry) error {
+	if c.dir == "" {
+		return nil
+	}
+
+	data, err := json.Marshal(ent)
+	if err != nil {
+		return err
+	}
+
+	return writeFile(c.entryPath(ent.URI), data)
+}
+
+func (c *Cache) entryPath(uri string) string {
+	sum := sha256.Sum256([]byte(uri))
+	return filepath.Join(c.dir, "index", hex.EncodeToString(sum[:])+".json")
+}
+
+// writeFile atomically writes data to the named file.
+func writeFile(filename string, data []byte) error {
+	if err := os.MkdirAll(filepath.Dir(filename), 0755); err != nil {
+		return err
+	}
+
+	tmp, err := ioutil.TempFile(filepath.Dir(filename), ".tmp-")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp.Name(), filename)
+}
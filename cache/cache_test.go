@@ -0,0 +1,82 @@
+package cache
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+	"time"
+)
+
+func TestCacheStore(t *testing.T) {
+	dir, err := ioutil.TempDir("", "jumble-cache")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	c := New(dir, TTL(time.Hour))
+
+	if _, _, found := c.Lookup("http://example.com/a.png"); found {
+		t.Fatal("found an entry in an empty cache")
+	}
+
+	for _, uri := range []string{"http://example.com/a.png", "http://example.com/b.png"} {
+		if err := c.Store(uri, []byte("same content"), `"v1"`, ""); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	ent, data, found := c.Lookup("http://example.com/b.png")
+	if !found {
+		t.Fatal("entry not found")
+	}
+
+	if got, want := string(data), "same content"; got != want {
+		t.Errorf("got [%v] want [%v]", got, want)
+	}
+
+	if got, want := ent.ETag, `"v1"`; got != want {
+		t.Errorf("got [%v] want [%v]", got, want)
+	}
+
+	if !c.Fresh(ent) {
+		t.Errorf("entry should be fresh")
+	}
+
+	blobs, err := ioutil.ReadDir(dir + "/blobs")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(blobs) != 1 {
+		t.Errorf("got [%d] blobs want [1]", len(blobs))
+	}
+
+	if New(dir, TTL(0)).Fresh(ent) {
+		t.Errorf("entry should be expired")
+	}
+
+	if !New(dir, TTL(0), Offline(true)).Fresh(ent) {
+		t.Errorf("entry should be fresh in offline mode")
+	}
+}
+
+func TestCacheClean(t *testing.T) {
+	dir, err := ioutil.TempDir("", "jumble-cache")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	c := New(dir)
+	if err := c.Store("http://example.com/a.png", []byte("data"), "", ""); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := c.Clean(); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, _, found := c.Lookup("http://example.com/a.png"); found {
+		t.Errorf("entry found after clean")
+	}
+}
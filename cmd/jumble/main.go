@@ -7,9 +7,12 @@ import (
 	"os"
 	"path/filepath"
 	"strings"
+	"time"
 
 	"github.com/lucasepe/jumble"
+	"github.com/lucasepe/jumble/cache"
 	"github.com/lucasepe/jumble/config"
+	"github.com/lucasepe/jumble/fetch"
 )
 
 const (
@@ -28,6 +31,13 @@ var (
 
 	flagTileSize int
 	flagOutput   string
+	flagOffline  bool
+	flagCacheTTL time.Duration
+	flagTimeout  time.Duration
+	flagImageKB  int
+	flagConfigKB int
+	flagFontKB   int
+	flagLenient  bool
 )
 
 func main() {
@@ -38,9 +48,26 @@ func main() {
 		os.Exit(2)
 	}
 
+	resCache, err := configureFetcher()
+	handleErr(err)
+
+	if flag.CommandLine.Arg(0) == "cache" {
+		handleErr(runCache(resCache, flag.Args()[1:]))
+		return
+	}
+
+	if flag.CommandLine.Arg(0) == "validate" {
+		ok, err := runValidate(flag.Args()[1:])
+		handleErr(err)
+		if !ok {
+			os.Exit(1)
+		}
+		return
+	}
+
 	uri := flag.Args()[0]
 
-	cfg, err := config.DecodeURI(uri)
+	cfg, err := config.DecodeURI(uri, config.Lenient(flagLenient))
 	handleErr(err)
 
 	if flagTileSize <= 16 {
@@ -54,6 +81,9 @@ func main() {
 	grid, err := jumble.NewGrid(cfg.Rows, cfg.Cols, flagTileSize,
 		jumble.GridBackground(cfg.Background),
 		jumble.GridMargin(cfg.Margin),
+		jumble.GridFont(cfg.Font),
+		jumble.GridFallbackFonts(cfg.FallbackFonts...),
+		jumble.GridShadow(cfg.Shadow),
 	)
 	handleErr(err)
 
@@ -67,8 +97,16 @@ func main() {
 		grid.DrawCoords()
 	}
 
-	for _, tile := range cfg.Tiles {
-		handleErr(tile.Plot(grid))
+	tiles := make([]jumble.Tile, 0, len(cfg.Order))
+	for _, id := range cfg.Order {
+		tiles = append(tiles, cfg.Tiles[id])
+	}
+	grid.Prefetch(8, tiles...)
+
+	for i, tile := range tiles {
+		if err := tile.Plot(grid); err != nil {
+			handleErr(&jumble.TileError{ID: cfg.Order[i], Err: err})
+		}
 	}
 
 	if len(flagOutput) <= 1 {
@@ -86,10 +124,14 @@ func configureFlags() {
 		fmt.Printf("Create diagrams stitching and connecting images.\n\n")
 
 		fmt.Print("USAGE:\n\n")
-		fmt.Printf("  %s [options] <hcl file or url>\n\n", name)
+		fmt.Printf("  %s [options] <hcl file or url>\n", name)
+		fmt.Printf("  %s validate [-json] <hcl file or url>\n", name)
+		fmt.Printf("  %s cache clean\n\n", name)
 
 		fmt.Print("EXAMPLE:\n\n")
 		fmt.Printf("  %s -s 64 -o test.png test.hcl\n", name)
+		fmt.Printf("  %s -offline -o test.png test.hcl\n", name)
+		fmt.Printf("  %s validate -json test.hcl\n", name)
 		fmt.Println()
 
 		fmt.Print("OPTIONS:\n\n")
@@ -105,10 +147,96 @@ func configureFlags() {
 
 	flag.CommandLine.IntVar(&flagTileSize, "s", 72, "cell size in pixel; min:16 max:96")
 	flag.CommandLine.StringVar(&flagOutput, "o", "", "write to file instead of stdout")
+	flag.CommandLine.BoolVar(&flagOffline, "offline", false, "serve remote icons and configs only from the cache")
+	flag.CommandLine.DurationVar(&flagCacheTTL, "cache-ttl", 24*time.Hour, "how long cached resources are used without revalidation")
+	flag.CommandLine.DurationVar(&flagTimeout, "timeout", 30*time.Second, "time limit of each HTTP request")
+	flag.CommandLine.IntVar(&flagImageKB, "max-image-kb", 200, "max size of an image in Kb")
+	flag.CommandLine.IntVar(&flagConfigKB, "max-config-kb", 100, "max size of an HCL configuration in Kb")
+	flag.CommandLine.IntVar(&flagFontKB, "max-font-kb", 2048, "max size of a font in Kb")
+	flag.CommandLine.BoolVar(&flagLenient, "lenient", false, "skip unknown tile types and attributes (instead of failing)")
 
 	flag.CommandLine.Parse(os.Args[1:])
 }
 
+// configureFetcher sets up the resources fetcher and its on-disk cache
+func configureFetcher() (*cache.Cache, error) {
+	dir, err := cache.DefaultDir()
+	if err != nil {
+		dir = ""
+	}
+
+	res := cache.New(dir,
+		cache.TTL(flagCacheTTL),
+		cache.Offline(flagOffline),
+	)
+
+	flagOpts := map[string]func(*fetch.Fetcher){
+		"timeout":       fetch.Timeout(flagTimeout),
+		"max-image-kb":  fetch.ImageLimit(int64(flagImageKB) * 1024),
+		"max-config-kb": fetch.ConfigLimit(int64(flagConfigKB) * 1024),
+		"max-font-kb":   fetch.FontLimit(int64(flagFontKB) * 1024),
+	}
+
+	opts := []func(*fetch.Fetcher){fetch.Cache(res)}
+	for _, opt := range flagOpts {
+		opts = append(opts, opt)
+	}
+
+	env, err := fetch.EnvOptions(configURI())
+	if err != nil {
+		return nil, err
+	}
+	opts = append(opts, env...)
+
+	// the flags set on the command line win over the environment
+	flag.CommandLine.Visit(func(f *flag.Flag) {
+		if opt, ok := flagOpts[f.Name]; ok {
+			opts = append(opts, opt)
+		}
+	})
+
+	fetch.Default = fetch.New(opts...)
+
+	return res, nil
+}
+
+// configURI returns the HCL file or url of the command
+// (an empty string for the cache command)
+func configURI() string {
+	args := flag.CommandLine.Args()
+	switch args[0] {
+	case "cache":
+		return ""
+	case "validate":
+		for _, el := range args[1:] {
+			if !strings.HasPrefix(el, "-") {
+				return el
+			}
+		}
+		return ""
+	}
+
+	return args[0]
+}
+
+// runCache executes the cache management commands
+func runCache(c *cache.Cache, args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("missing cache command (available: clean)")
+	}
+
+	switch args[0] {
+	case "clean":
+		if err := c.Clean(); err != nil {
+			return err
+		}
+		fmt.Fprintf(os.Stderr, "cache cleaned: %s\n", c.Dir())
+		return nil
+	}
+
+	return fmt.Errorf("unknown cache command: %s (available: clean)", args[0])
+}
+
 // handleErr check for an error and eventually exit
 func handleErr(err error) {
 	if err != nil {
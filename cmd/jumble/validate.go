@@ -0,0 +1,109 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+
+	"github.com/hashicorp/hcl2/hcl"
+	"github.com/lucasepe/jumble/config"
+)
+
+// jsonDiagnostic is the JSON form of an HCL diagnostic
+type jsonDiagnostic struct {
+	Severity string     `json:"severity"`
+	Summary  string     `json:"summary"`
+	Detail   string     `json:"detail,omitempty"`
+	Range    *jsonRange `json:"range,omitempty"`
+}
+
+type jsonRange struct {
+	Filename string  `json:"filename"`
+	Start    jsonPos `json:"start"`
+	End      jsonPos `json:"end"`
+}
+
+type jsonPos struct {
+	Line   int `json:"line"`
+	Column int `json:"column"`
+	Byte   int `json:"byte"`
+}
+
+// runValidate checks an HCL file and prints the problems found;
+// it returns false when there are errors (warnings are allowed)
+func runValidate(args []string) (bool, error) {
+	fs := flag.NewFlagSet("validate", flag.ContinueOnError)
+	fs.SetOutput(ioutil.Discard)
+	asJSON := fs.Bool("json", false, "print the diagnostics as JSON")
+	if err := fs.Parse(args); err != nil {
+		return false, err
+	}
+
+	if fs.Arg(0) == "" {
+		return false, fmt.Errorf("missing hcl file or url to validate")
+	}
+	uri := fs.Arg(0)
+
+	diags, files, err := config.ValidateURI(uri, config.Lenient(flagLenient))
+	if err != nil {
+		return false, err
+	}
+
+	if *asJSON {
+		err = writeJSONDiagnostics(diags)
+	} else {
+		err = writeTextDiagnostics(uri, diags, files)
+	}
+
+	return !diags.HasErrors(), err
+}
+
+// writeTextDiagnostics prints the diagnostics with the source snippets
+func writeTextDiagnostics(uri string, diags hcl.Diagnostics, files map[string]*hcl.File) error {
+	if len(diags) == 0 {
+		fmt.Printf("%s: no problems found\n", uri)
+		return nil
+	}
+
+	wr := hcl.NewDiagnosticTextWriter(os.Stdout, files, 78, false)
+	return wr.WriteDiagnostics(diags)
+}
+
+// writeJSONDiagnostics prints the diagnostics as a JSON document
+func writeJSONDiagnostics(diags hcl.Diagnostics) error {
+	res := struct {
+		Valid       bool             `json:"valid"`
+		Diagnostics []jsonDiagnostic `json:"diagnostics"`
+	}{
+		Valid:       !diags.HasErrors(),
+		Diagnostics: []jsonDiagnostic{},
+	}
+
+	for _, el := range diags {
+		diag := jsonDiagnostic{
+			Severity: "error",
+			Summary:  el.Summary,
+			Detail:   el.Detail,
+		}
+
+		if el.Severity == hcl.DiagWarning {
+			diag.Severity = "warning"
+		}
+
+		if rng := el.Subject; rng != nil {
+			diag.Range = &jsonRange{
+				Filename: rng.Filename,
+				Start:    jsonPos{Line: rng.Start.Line, Column: rng.Start.Column, Byte: rng.Start.Byte},
+				End:      jsonPos{Line: rng.End.Line, Column: rng.End.Column, Byte: rng.End.Byte},
+			}
+		}
+
+		res.Diagnostics = append(res.Diagnostics, diag)
+	}
+
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	return enc.Encode(res)
+}
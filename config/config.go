@@ -2,20 +2,26 @@ package config
 
 import (
 	"fmt"
+	"math"
+	"sort"
+	"strconv"
 	"strings"
 
+	"github.com/fogleman/gg"
 	"github.com/hashicorp/hcl2/gohcl"
 	"github.com/hashicorp/hcl2/hcl"
+	"github.com/hashicorp/hcl2/hcl/hclsyntax"
 	"github.com/hashicorp/hcl2/hclparse"
 	"github.com/lucasepe/jumble"
-	"github.com/pkg/errors"
 	"github.com/teris-io/shortid"
 	"github.com/zclconf/go-cty/cty"
+	"github.com/zclconf/go-cty/cty/convert"
 	"github.com/zclconf/go-cty/cty/function"
 	"github.com/zclconf/go-cty/cty/function/stdlib"
+	"github.com/zclconf/go-cty/cty/gocty"
 
-	"github.com/lucasepe/jumble/config/data"
 	"github.com/lucasepe/jumble/config/funcs"
+	"github.com/lucasepe/jumble/fetch"
 )
 
 // Config defines a grid layout with all the tiles.
@@ -27,8 +33,23 @@ type Config struct {
 	Grid       bool
 	Border     bool
 	Hints      bool
+	Font       string
+	// FallbackFonts are the font families used (in order)
+	// for the label characters missing in the label font.
+	FallbackFonts []string
+	// Shadow is the default drop shadow of
+	// frames, label boxes, shapes and icons.
+	Shadow *jumble.Shadow
 
 	Tiles map[string]jumble.Tile
+	// Order are the tile IDs in plotting order: the
+	// frames first, then the other tiles (both in
+	// declaration order, the module tiles last).
+	Order []string
+
+	// decoded are all the decoded tiles in declaration
+	// order (also the ones with a duplicate ID)
+	decoded []decodedTile
 }
 
 // rootHCL is the helper struct for parsing our HCL file.
@@ -40,59 +61,154 @@ type rootHCL struct {
 	Grid       bool   `hcl:"grid,optional"`
 	Border     bool   `hcl:"border,optional"`
 	Hints      bool   `hcl:"hints,optional"`
+	Font       string `hcl:"default_font,optional"`
+
+	FallbackFonts []string   `hcl:"fallback_fonts,optional"`
+	Shadow        *shadowHCL `hcl:"shadow,block"`
+
+	AssetPacks []*struct {
+		Name string         `hcl:"name,label"`
+		Path *hcl.Attribute `hcl:"path"`
+	} `hcl:"asset_pack,block"`
+
+	Fonts []*struct {
+		Name       string         `hcl:"name,label"`
+		Path       *hcl.Attribute `hcl:"path"`
+		Bold       *hcl.Attribute `hcl:"bold,optional"`
+		Italic     *hcl.Attribute `hcl:"italic,optional"`
+		BoldItalic *hcl.Attribute `hcl:"bold_italic,optional"`
+	} `hcl:"font,block"`
 
 	Variables []*struct {
 		Name  string         `hcl:"name,label"`
 		Value hcl.Attributes `hcl:"value,remain"`
 	} `hcl:"var,block"`
 
-	Tiles []*struct {
-		Kind    string   `hcl:"type,label"`
-		ID      string   `hcl:"id,label"`
-		HCLBody hcl.Body `hcl:",remain"`
-	} `hcl:"tile,block"`
-}
+	Tiles []*tileHCL `hcl:"tile,block"`
 
-// DecodeURI parses the given uri with our HCL content.
-func DecodeURI(uri string) (Config, error) {
-	const bytesLimit = 100 * 1024
+	Includes []*struct {
+		Path string `hcl:"path,label"`
+	} `hcl:"include,block"`
 
-	if strings.HasPrefix(uri, "http") {
-		body, err := data.FetchFromURI(uri, bytesLimit)
-		if err != nil {
-			return Config{}, errors.Wrapf(err, "fetching '%s'", uri)
-		}
+	Modules []*moduleHCL `hcl:"module,block"`
+}
 
-		return Decode(body, uri)
-	}
+// tileHCL is the HCL 'tile' block.
+type tileHCL struct {
+	Kind    string   `hcl:"type,label"`
+	ID      string   `hcl:"id,label"`
+	HCLBody hcl.Body `hcl:",remain"`
+
+	// module is the name of a module block
+	// (decoded along with the tiles)
+	module string
+	// block describes the HCL block
+	block tileBlock
+}
 
-	body, err := data.FetchFromFile(uri, bytesLimit)
+// DecodeURI parses the given uri with our HCL content.
+func DecodeURI(uri string, opts ...func(*DecodeOptions)) (Config, error) {
+	body, err := fetch.Default.Config(uri)
 	if err != nil {
-		return Config{}, errors.Wrapf(err, "fetching '%s'", uri)
+		return Config{}, err
 	}
 
-	return Decode(body, uri)
+	return Decode(body, uri, opts...)
+}
+
+// DecodeOptions are the decoding settings.
+type DecodeOptions struct {
+	// Lenient skips the unknown tile types and ignores
+	// the unknown attributes and blocks (e.g. of files
+	// written for a newer version).
+	Lenient bool
+
+	// duplicates decodes the tiles with a duplicate
+	// ID too (only the first one is in the tiles map)
+	duplicates bool
+}
+
+// Lenient enables the lenient decoding mode
+func Lenient(val bool) func(*DecodeOptions) {
+	return func(o *DecodeOptions) {
+		o.Lenient = val
+	}
 }
 
 // Decode parses the given buffer with our HCL content.
 // The `uri` string is just for debugging purposes.
 // On success this function returns a Config struct.
-func Decode(data []byte, uri string) (Config, error) {
+func Decode(data []byte, uri string, opts ...func(*DecodeOptions)) (Config, error) {
+	var options DecodeOptions
+	for _, opt := range opts {
+		opt(&options)
+	}
 
-	// Instantiate an HCL parser with the source byte slice.
-	parser := hclparse.NewParser()
+	return decode(hclparse.NewParser(), data, uri, options, nil)
+}
 
-	srcHCL, diags := parser.ParseHCL(data, uri)
+// decode decodes a diagram file; call holds the parameters of
+// the module block instantiating it (nil for the main file).
+// The parser holds all the parsed files (also the included
+// and the module ones).
+func decode(parser *hclparse.Parser, data []byte, uri string, options DecodeOptions, call *moduleCall) (Config, error) {
+	srcHCL, diags := parseHCL(parser, data, uri)
 	if diags.HasErrors() {
 		return Config{}, fmt.Errorf("error parsing HCL file: %w", diags)
 	}
 
+	// Load the included files
+	files, err := loadIncludes(parser, srcHCL, uri)
+	if err != nil {
+		return Config{}, err
+	}
+
+	rootBody := srcHCL.Body
+	if len(files) > 1 {
+		rootBody = hcl.MergeFiles(files)
+	}
+
+	if options.Lenient {
+		rootBody = lenientBody{rootBody}
+	}
+
 	// Start the first pass of decoding
 	var root rootHCL
-	if diags := gohcl.DecodeBody(srcHCL.Body, nil, &root); diags.HasErrors() {
+	if diags := gohcl.DecodeBody(rootBody, nil, &root); diags.HasErrors() {
 		return Config{}, fmt.Errorf("error decoding HCL configuration: %w", diags)
 	}
 
+	// Register all user defined asset packs
+	for _, ap := range root.AssetPacks {
+		path, err := pathValue(ap.Path)
+		if err != nil {
+			return Config{}, err
+		}
+
+		if err := jumble.RegisterAssetPackPath(ap.Name, path); err != nil {
+			return Config{}, fmt.Errorf("error registering asset pack '%s': %w", ap.Name, err)
+		}
+	}
+
+	// Load and register all the font families
+	for _, el := range root.Fonts {
+		var paths [4]string
+		for i, attr := range []*hcl.Attribute{el.Path, el.Bold, el.Italic, el.BoldItalic} {
+			if paths[i], err = pathValue(attr); err != nil {
+				return Config{}, err
+			}
+		}
+
+		fam, err := loadFontFamily(paths[0], paths[1], paths[2], paths[3])
+		if err != nil {
+			return Config{}, fmt.Errorf("error loading font '%s': %w", el.Name, err)
+		}
+
+		if err := jumble.RegisterFontFamily(el.Name, fam); err != nil {
+			return Config{}, err
+		}
+	}
+
 	// Decode all variables
 	variables := map[string]cty.Value{}
 	for _, v := range root.Variables {
@@ -108,6 +224,14 @@ func Decode(data []byte, uri string) (Config, error) {
 		variables[v.Name] = val
 	}
 
+	chain := []string{uri}
+	if call != nil {
+		if err := call.bind(variables); err != nil {
+			return Config{}, err
+		}
+		chain = append(call.chain, uri)
+	}
+
 	cfg := Config{
 		Rows:       root.Rows,
 		Cols:       root.Cols,
@@ -116,7 +240,11 @@ func Decode(data []byte, uri string) (Config, error) {
 		Grid:       root.Grid,
 		Border:     root.Border,
 		Hints:      root.Hints,
+		Font:       root.Font,
 		Tiles:      map[string]jumble.Tile{},
+
+		FallbackFonts: root.FallbackFonts,
+		Shadow:        root.Shadow.shadow(),
 	}
 
 	// Call a helper function which creates an HCL context for use in
@@ -126,48 +254,132 @@ func Decode(data []byte, uri string) (Config, error) {
 		return Config{}, fmt.Errorf("error creating HCL evaluation context: %w", err)
 	}
 
-	// Start decoding
-	for _, tile := range root.Tiles {
-		if len(strings.TrimSpace(tile.ID)) == 0 {
+	var ranges []*hclsyntax.Block
+	for _, f := range files {
+		ranges = append(ranges, tileSyntaxBlocks(f.Body)...)
+	}
+
+	ids := map[string]hcl.Range{}
+	for i, tile := range root.Tiles {
+		named := len(strings.TrimSpace(tile.ID)) > 0
+		if !named {
 			if tile.ID, err = shortid.Generate(); err != nil {
 				return Config{}, err
 			}
 		}
 
-		switch t := tile.Kind; t {
-		case "icon":
-			el, err := decodeIcon(tile.HCLBody, evalContext)
-			if err != nil {
-				return Config{}, err
+		var idRng hcl.Range
+		tile.block = tileBlock{kind: tile.Kind, named: named}
+		if i < len(ranges) {
+			tile.block.rng, tile.block.kindRng = ranges[i].DefRange(), ranges[i].LabelRanges[0]
+			idRng = ranges[i].LabelRanges[1]
+		}
+
+		if prev, ok := ids[tile.ID]; ok && !options.duplicates {
+			return Config{}, fmt.Errorf("error decoding HCL configuration: %w", duplicateID(tile.ID, prev, idRng))
+		}
+		ids[tile.ID] = idRng
+	}
+
+	// The module blocks are decoded along with the tiles
+	nodes := root.Tiles
+	for _, m := range root.Modules {
+		id := moduleID(m.Name)
+		for _, el := range nodes {
+			if el.ID == id {
+				return Config{}, fmt.Errorf("error decoding HCL configuration: %w", &hcl.Diagnostic{
+					Severity: hcl.DiagError,
+					Summary:  "Duplicate module",
+					Detail:   fmt.Sprintf("The module name '%s' is already used.", m.Name),
+					Subject:  m.HCLBody.MissingItemRange().Ptr(),
+				})
 			}
-			cfg.Tiles[tile.ID] = &el
+		}
+
+		nodes = append(nodes, &tileHCL{ID: id, HCLBody: m.HCLBody, module: m.Name})
+	}
 
-		case "label":
-			el, err := decodeLabel(tile.HCLBody, evalContext)
+	// Decode the referenced tiles first
+	tiles, diags := sortTiles(nodes, evalContext)
+	if diags.HasErrors() {
+		return Config{}, fmt.Errorf("error decoding HCL configuration: %w", diags)
+	}
+
+	// Start decoding
+	modules := map[string][]string{}
+	decoded := map[*tileHCL][]decodedTile{}
+	for _, tile := range tiles {
+		start := len(cfg.decoded)
+		body := tile.HCLBody
+		if options.Lenient {
+			body = lenientBody{body}
+		}
+
+		if tile.module != "" {
+			modIDs, err := cfg.decodeModule(parser, tile.module, body, evalContext, options, chain)
 			if err != nil {
 				return Config{}, err
 			}
-			cfg.Tiles[tile.ID] = &el
+			modules[tile.module] = modIDs
+			decoded[tile] = cfg.decoded[start:]
+			continue
+		}
 
-		case "frame":
-			el, err := decodeFrame(tile.HCLBody, evalContext)
-			if err != nil {
-				return Config{}, err
+		decode, ok := tileDecoder(tile.Kind)
+		if !ok {
+			if options.Lenient {
+				continue
 			}
-			cfg.Tiles[tile.ID] = &el
+			return Config{}, fmt.Errorf("error decoding HCL configuration: %w",
+				unknownTileType(tile.Kind, tile.block.kindRng))
+		}
 
-		default:
-			el, err := decodeConnector(tile.HCLBody, evalContext, tile.Kind)
-			if err != nil {
-				if _, ok := err.(*unknowTileTypeError); ok {
-					continue
-				}
-				return Config{}, err
+		el, err := decode(body, evalContext)
+		if err != nil {
+			return Config{}, err
+		}
+
+		// set the bounds around the enclosed tiles
+		if fc, ok := el.(*frameContains); ok {
+			if err := fc.enclose(cfg.Tiles, cfg.Rows, cfg.Cols); err != nil {
+				return Config{}, fmt.Errorf("error decoding HCL configuration: %w", err)
 			}
-			cfg.Tiles[tile.ID] = &el
+			el = fc.Frame
+		}
+		cfg.decoded = append(cfg.decoded, decodedTile{id: tile.ID, tile: el, block: tile.block})
+		decoded[tile] = cfg.decoded[start:]
+		if _, ok := cfg.Tiles[tile.ID]; !ok {
+			cfg.Tiles[tile.ID] = el
+		}
+	}
+
+	// keep the decoded tiles in declaration order
+	res := make([]decodedTile, 0, len(cfg.decoded))
+	for _, el := range nodes {
+		res = append(res, decoded[el]...)
+	}
+	cfg.decoded = res
+
+	seen := map[string]bool{}
+	for _, el := range nodes {
+		if el.module != "" {
+			cfg.Order = append(cfg.Order, modules[el.module]...)
+			continue
+		}
+
+		// the lenient mode skips the unknown tiles
+		if _, ok := cfg.Tiles[el.ID]; ok && !seen[el.ID] {
+			cfg.Order = append(cfg.Order, el.ID)
 		}
+		seen[el.ID] = true
 	}
 
+	sort.SliceStable(cfg.Order, func(i, j int) bool {
+		_, fi := cfg.Tiles[cfg.Order[i]].(*jumble.Frame)
+		_, fj := cfg.Tiles[cfg.Order[j]].(*jumble.Frame)
+		return fi && !fj
+	})
+
 	return cfg, nil
 }
 
@@ -185,83 +397,535 @@ func createContext(vars map[string]cty.Value, tiles map[string]jumble.Tile) (*hc
 			"col":      funcs.ColOfFunc(tiles),
 			"add":      stdlib.AddFunc,
 			"subtract": stdlib.SubtractFunc,
+
+			"move":      funcs.MoveFunc(tiles),
+			"right_of":  funcs.RightOfFunc(tiles),
+			"left_of":   funcs.LeftOfFunc(tiles),
+			"below":     funcs.BelowFunc(tiles),
+			"above":     funcs.AboveFunc(tiles),
+			"align_row": funcs.AlignRowFunc(tiles),
+			"align_col": funcs.AlignColFunc(tiles),
+			"center_of": funcs.CenterOfFunc(tiles),
+			"between":   funcs.BetweenFunc(tiles),
 		},
 	}, nil
 }
 
+// pathValue returns the value of a path attribute resolved
+// against the file declaring it (empty when attr is nil).
+func pathValue(attr *hcl.Attribute) (string, error) {
+	if attr == nil {
+		return "", nil
+	}
+
+	var res string
+	if diags := gohcl.DecodeExpression(attr.Expr, nil, &res); diags.HasErrors() {
+		return "", fmt.Errorf("error decoding HCL configuration: %w", diags)
+	}
+
+	return resolveURI(attr.Range.Filename, res), nil
+}
+
+// loadFontFamily loads the font variants
+// (only the regular one is mandatory)
+func loadFontFamily(regular, bold, italic, boldItalic string) (jumble.FontFamily, error) {
+	var res jumble.FontFamily
+
+	variants := []struct {
+		uri  string
+		dest **jumble.Font
+	}{
+		{regular, &res.Regular},
+		{bold, &res.Bold},
+		{italic, &res.Italic},
+		{boldItalic, &res.BoldItalic},
+	}
+
+	for _, el := range variants {
+		if el.uri == "" {
+			continue
+		}
+
+		f, err := jumble.LoadFont(el.uri)
+		if err != nil {
+			return jumble.FontFamily{}, err
+		}
+		*el.dest = f
+	}
+
+	return res, nil
+}
+
 // decodeIcon decode the HCL 'icon' block
-func decodeIcon(body hcl.Body, ctx *hcl.EvalContext) (jumble.Icon, error) {
+func decodeIcon(body hcl.Body, ctx *hcl.EvalContext) (jumble.Tile, error) {
 	var tmp struct {
-		Row int    `hcl:"row"`
-		Col int    `hcl:"col"`
-		Fit bool   `hcl:"fit,optional"`
-		URI string `hcl:"uri"`
+		Row     int     `hcl:"row"`
+		Col     int     `hcl:"col"`
+		Fit     string  `hcl:"fit,optional"`
+		Padding float64 `hcl:"padding,optional"`
+		Anchor  string  `hcl:"anchor,optional"`
+		URI     string  `hcl:"uri"`
+
+		Overlay      string `hcl:"overlay,optional"`
+		OverlayColor string `hcl:"overlay_color,optional"`
+
+		Badge *struct {
+			Corner    string  `hcl:"corner,optional"`
+			Text      string  `hcl:"text,optional"`
+			Count     *int    `hcl:"count,optional"`
+			Color     string  `hcl:"color,optional"`
+			TextColor string  `hcl:"text_color,optional"`
+			Size      float64 `hcl:"size,optional"`
+		} `hcl:"badge,block"`
+
+		Shadow *shadowHCL `hcl:"shadow,block"`
 	}
-	tmp.Fit = true
 
 	if diags := gohcl.DecodeBody(body, ctx, &tmp); diags.HasErrors() {
-		return jumble.Icon{}, fmt.Errorf("error decoding HCL configuration: %w", diags)
+		return nil, fmt.Errorf("error decoding HCL configuration: %w", diags)
+	}
+
+	// keep the old boolean 'fit' attribute working
+	switch tmp.Fit {
+	case "", "true":
+		tmp.Fit = jumble.FitContain
+	case "false":
+		tmp.Fit = jumble.FitNone
 	}
 
-	return jumble.Icon{
+	res := jumble.Icon{
 		Row: tmp.Row, Col: tmp.Col,
-		Fit: tmp.Fit,
-		URI: tmp.URI,
-	}, nil
+		Fit:          tmp.Fit,
+		Padding:      tmp.Padding,
+		Anchor:       tmp.Anchor,
+		URI:          tmp.URI,
+		Overlay:      tmp.Overlay,
+		OverlayColor: tmp.OverlayColor,
+		Shadow:       tmp.Shadow.shadow(),
+	}
+
+	if b := tmp.Badge; b != nil {
+		res.Badge = &jumble.Badge{
+			Corner:    b.Corner,
+			Text:      b.Text,
+			Color:     b.Color,
+			TextColor: b.TextColor,
+			Size:      b.Size,
+		}
+
+		if b.Count != nil {
+			res.Badge.Text = strconv.Itoa(*b.Count)
+		}
+	}
+
+	return &res, nil
+}
+
+// frameContains is a frame with the list of tiles it encloses.
+type frameContains struct {
+	*jumble.Frame
+	ids     []string
+	padding int
+	rng     hcl.Range
 }
 
-// decodeFrame decode the HCL 'frame' block
-func decodeFrame(body hcl.Body, ctx *hcl.EvalContext) (jumble.Frame, error) {
+// decodeFrame decode the HCL 'frame' block; a frame enclosing
+// other tiles is returned as a *frameContains to be resolved.
+func decodeFrame(body hcl.Body, ctx *hcl.EvalContext) (jumble.Tile, error) {
 	var tmp struct {
-		Left        int     `hcl:"left"`
-		Top         int     `hcl:"top"`
-		Right       int     `hcl:"right"`
-		Bottom      int     `hcl:"bottom"`
+		Left        int     `hcl:"left,optional"`
+		Top         int     `hcl:"top,optional"`
+		Right       int     `hcl:"right,optional"`
+		Bottom      int     `hcl:"bottom,optional"`
 		Dashes      float64 `hcl:"dashes,optional"`
 		Color       string  `hcl:"color,optional"`
 		Stroke      bool    `hcl:"stroke,optional"`
 		StrokeWidth float64 `hcl:"stroke_width,optional"`
 		Oval        bool    `hcl:"oval,optional"`
+
+		Title           string `hcl:"title,optional"`
+		TitleColor      string `hcl:"title_color,optional"`
+		TitleBackground string `hcl:"title_background,optional"`
+		TitleIcon       string `hcl:"title_icon,optional"`
+		TitleStyle      string `hcl:"title_style,optional"`
+
+		Fill         string  `hcl:"fill,optional"`
+		StrokeColor  string  `hcl:"stroke_color,optional"`
+		CornerRadius float64 `hcl:"corner_radius,optional"`
+		Gradient     *struct {
+			Type   string   `hcl:"type,optional"`
+			Colors []string `hcl:"colors"`
+			Angle  float64  `hcl:"angle,optional"`
+		} `hcl:"gradient,block"`
+
+		Contains hcl.Expression `hcl:"contains,optional"`
+		Padding  int            `hcl:"padding,optional"`
+
+		Align      string  `hcl:"align,optional"`
+		Inset      float64 `hcl:"inset,optional"`
+		InsetCells float64 `hcl:"inset_cells,optional"`
+
+		Shadow *shadowHCL `hcl:"shadow,block"`
 	}
 
 	if diags := gohcl.DecodeBody(body, ctx, &tmp); diags.HasErrors() {
-		return jumble.Frame{}, fmt.Errorf("error decoding HCL configuration: %w", diags)
+		return nil, fmt.Errorf("error decoding HCL configuration: %w", diags)
+	}
+
+	var contains *frameContains
+	var err error
+	if val, diags := tmp.Contains.Value(ctx); diags.HasErrors() {
+		return nil, fmt.Errorf("error decoding HCL configuration: %w", diags)
+	} else if !val.IsNull() {
+		contains = &frameContains{padding: tmp.Padding, rng: tmp.Contains.Range()}
+		if val, err = convert.Convert(val, cty.List(cty.String)); err == nil {
+			err = gocty.FromCtyValue(val, &contains.ids)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("error decoding HCL configuration: %s: contains: %w", contains.rng, err)
+		}
+
+		// frames grouping tiles enclose their cells
+		if tmp.Align == "" {
+			tmp.Align = jumble.FrameAlignOuter
+		}
+	} else if tmp.Left == 0 && tmp.Top == 0 && tmp.Right == 0 && tmp.Bottom == 0 {
+		return nil, fmt.Errorf("error decoding HCL configuration: frame needs 'left', 'top', 'right' and 'bottom' or 'contains'")
 	}
 
-	return jumble.NewFrame(tmp.Left, tmp.Top, tmp.Right, tmp.Bottom,
+	opts := []func(*jumble.Frame){
 		jumble.FrameOval(tmp.Oval),
 		jumble.FrameColor(tmp.Color),
 		jumble.FrameStroke(tmp.Stroke),
 		jumble.FrameDashes(tmp.Dashes),
 		jumble.FrameStrokeWidth(tmp.StrokeWidth),
-	), nil
+		jumble.FrameTitle(tmp.Title),
+		jumble.FrameTitleBackground(tmp.TitleBackground),
+		jumble.FrameTitleIcon(tmp.TitleIcon),
+		jumble.FrameFill(tmp.Fill),
+		jumble.FrameStrokeColor(tmp.StrokeColor),
+		jumble.FrameCornerRadius(tmp.CornerRadius),
+		jumble.FrameAlign(tmp.Align),
+		jumble.FrameInset(tmp.Inset),
+		jumble.FrameInsetCells(tmp.InsetCells),
+		jumble.FrameShadow(tmp.Shadow.shadow()),
+	}
+
+	if gr := tmp.Gradient; gr != nil {
+		opts = append(opts, jumble.FrameGradient(&jumble.Gradient{
+			Type:   gr.Type,
+			Colors: gr.Colors,
+			Angle:  gr.Angle,
+		}))
+	}
+
+	if tmp.TitleColor != "" {
+		opts = append(opts, jumble.FrameTitleColor(tmp.TitleColor))
+	}
+
+	if tmp.TitleStyle != "" {
+		opts = append(opts, jumble.FrameTitleStyle(tmp.TitleStyle))
+	}
+
+	res := jumble.NewFrame(tmp.Left, tmp.Top, tmp.Right, tmp.Bottom, opts...)
+	if contains != nil {
+		contains.Frame = &res
+		return contains, nil
+	}
+
+	return &res, nil
+}
+
+// enclose sets the frame bounds around the contained tiles;
+// rows and cols are the grid size.
+func (fc *frameContains) enclose(tiles map[string]jumble.Tile, rows, cols int) error {
+	if len(fc.ids) == 0 {
+		return &hcl.Diagnostic{
+			Severity: hcl.DiagError,
+			Summary:  "Empty frame",
+			Detail:   "The 'contains' list of a frame can't be empty.",
+			Subject:  fc.rng.Ptr(),
+		}
+	}
+
+	top, left := math.MaxInt32, math.MaxInt32
+	bottom, right := math.MinInt32, math.MinInt32
+	for _, id := range fc.ids {
+		t, ok := tiles[id]
+		if !ok {
+			return &hcl.Diagnostic{
+				Severity: hcl.DiagError,
+				Summary:  "Unknown tile",
+				Detail:   fmt.Sprintf("The frame contains the tile '%s' which is not defined.", id),
+				Subject:  fc.rng.Ptr(),
+			}
+		}
+
+		row, col := t.Location()
+		rs, cs := 1, 1
+		if sp, ok := t.(jumble.Spanner); ok {
+			rs, cs = sp.Span()
+		}
+
+		top, left = minInt(top, row), minInt(left, col)
+		bottom, right = maxInt(bottom, row+rs-1), maxInt(right, col+cs-1)
+	}
+
+	// the frame fields are (row, col) of the corners
+	fc.Frame.Left = maxInt(top-fc.padding, 0)
+	fc.Frame.Top = maxInt(left-fc.padding, 0)
+	fc.Frame.Right = minInt(bottom+fc.padding, rows-1)
+	fc.Frame.Bottom = minInt(right+fc.padding, cols-1)
+
+	return nil
+}
+
+func minInt(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+func maxInt(a, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
+
+// shadowHCL is the HCL 'shadow' block
+type shadowHCL struct {
+	OffsetX *float64 `hcl:"offset_x,optional"`
+	OffsetY *float64 `hcl:"offset_y,optional"`
+	Blur    float64  `hcl:"blur,optional"`
+	Color   string   `hcl:"color,optional"`
+	Enabled *bool    `hcl:"enabled,optional"`
+}
+
+func (s *shadowHCL) shadow() *jumble.Shadow {
+	if s == nil {
+		return nil
+	}
+
+	res := &jumble.Shadow{
+		Blur:     s.Blur,
+		Color:    s.Color,
+		Disabled: s.Enabled != nil && !*s.Enabled,
+	}
+
+	// an unset offset is the default one
+	if s.OffsetX != nil || s.OffsetY != nil {
+		res.Offset = &gg.Point{}
+		if s.OffsetX != nil {
+			res.Offset.X = *s.OffsetX
+		}
+		if s.OffsetY != nil {
+			res.Offset.Y = *s.OffsetY
+		}
+	}
+
+	return res
 }
 
 // decodeLabel decode the HCL 'label' block
-func decodeLabel(body hcl.Body, ctx *hcl.EvalContext) (jumble.Label, error) {
+func decodeLabel(body hcl.Body, ctx *hcl.EvalContext) (jumble.Tile, error) {
 	var tmp struct {
 		Row        int     `hcl:"row"`
 		Col        int     `hcl:"col"`
 		Text       string  `hcl:"text"`
-		FontSize   float64 `hcl:"font_size,optional"`
+		FontSize   string  `hcl:"font_size,optional"`
 		Color      string  `hcl:"color,optional"`
 		Background string  `hcl:"background,optional"`
 		Angle      float64 `hcl:"angle,optional"`
+
+		MaxWidth    float64  `hcl:"max_width,optional"`
+		LineSpacing *float64 `hcl:"line_spacing,optional"`
+		TextAlign   string   `hcl:"text_align,optional"`
+
+		Font   string `hcl:"font,optional"`
+		Bold   bool   `hcl:"bold,optional"`
+		Italic bool   `hcl:"italic,optional"`
+
+		Align   string  `hcl:"align,optional"`
+		VAlign  string  `hcl:"valign,optional"`
+		OffsetX float64 `hcl:"offset_x,optional"`
+		OffsetY float64 `hcl:"offset_y,optional"`
+
+		Markup bool `hcl:"markup,optional"`
+
+		RowSpan     *int    `hcl:"row_span,optional"`
+		ColSpan     *int    `hcl:"col_span,optional"`
+		MinFontSize float64 `hcl:"min_font_size,optional"`
+
+		Padding      *float64   `hcl:"padding,optional"`
+		CornerRadius *float64   `hcl:"corner_radius,optional"`
+		BorderColor  string     `hcl:"border_color,optional"`
+		BorderWidth  *float64   `hcl:"border_width,optional"`
+		Shadow       *shadowHCL `hcl:"shadow,block"`
 	}
 
 	if diags := gohcl.DecodeBody(body, ctx, &tmp); diags.HasErrors() {
-		return jumble.Label{}, fmt.Errorf("error decoding HCL configuration: %w", diags)
+		return nil, fmt.Errorf("error decoding HCL configuration: %w", diags)
 	}
 
-	return jumble.NewLabel(tmp.Row, tmp.Col, tmp.Text,
+	opts := []func(*jumble.Label){
 		jumble.LabelColor(tmp.Color),
 		jumble.LabelBackground(tmp.Background),
-		jumble.LabelFontSize(tmp.FontSize),
-		jumble.LabelAngle(tmp.Angle)), nil
+		jumble.LabelAngle(tmp.Angle),
+		jumble.LabelMaxWidth(tmp.MaxWidth),
+		jumble.LabelTextAlign(tmp.TextAlign),
+		jumble.LabelFont(tmp.Font),
+		jumble.LabelBold(tmp.Bold),
+		jumble.LabelItalic(tmp.Italic),
+		jumble.LabelAlign(tmp.Align),
+		jumble.LabelVAlign(tmp.VAlign),
+		jumble.LabelOffset(tmp.OffsetX, tmp.OffsetY),
+		jumble.LabelMarkup(tmp.Markup),
+	}
+
+	if tmp.LineSpacing != nil {
+		opts = append(opts, jumble.LabelLineSpacing(*tmp.LineSpacing))
+	}
+
+	if tmp.Padding != nil {
+		opts = append(opts, jumble.LabelPadding(*tmp.Padding))
+	}
+
+	if tmp.CornerRadius != nil {
+		opts = append(opts, jumble.LabelCornerRadius(*tmp.CornerRadius))
+	}
+
+	if tmp.BorderColor != "" {
+		width := 1.0
+		if tmp.BorderWidth != nil {
+			width = *tmp.BorderWidth
+		}
+		opts = append(opts, jumble.LabelBorder(tmp.BorderColor, width))
+	}
+
+	if tmp.Shadow != nil {
+		opts = append(opts, jumble.LabelShadow(tmp.Shadow.shadow()))
+	}
+
+	if tmp.RowSpan != nil {
+		opts = append(opts, jumble.LabelRowSpan(*tmp.RowSpan))
+	}
+
+	if tmp.ColSpan != nil {
+		opts = append(opts, jumble.LabelColSpan(*tmp.ColSpan))
+	}
+
+	switch tmp.FontSize {
+	case "":
+	case "auto":
+		opts = append(opts, jumble.LabelAutoFontSize(tmp.MinFontSize))
+	default:
+		size, err := strconv.ParseFloat(tmp.FontSize, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid font_size: %s (must be a number or 'auto')", tmp.FontSize)
+		}
+		opts = append(opts, jumble.LabelFontSize(size))
+	}
+
+	res := jumble.NewLabel(tmp.Row, tmp.Col, tmp.Text, opts...)
+	return &res, nil
+}
+
+// decodeShape decode the HCL 'shape' block
+func decodeShape(body hcl.Body, ctx *hcl.EvalContext) (jumble.Tile, error) {
+	var tmp struct {
+		Row         int            `hcl:"row"`
+		Col         int            `hcl:"col"`
+		Shape       hcl.Expression `hcl:"shape"`
+		Fill        *string        `hcl:"fill,optional"`
+		Stroke      *string        `hcl:"stroke,optional"`
+		StrokeWidth *float64       `hcl:"stroke_width,optional"`
+		Text        string         `hcl:"text,optional"`
+		TextColor   string         `hcl:"text_color,optional"`
+		FontSize    float64        `hcl:"font_size,optional"`
+		Markup      bool           `hcl:"markup,optional"`
+		RowSpan     *int           `hcl:"row_span,optional"`
+		ColSpan     *int           `hcl:"col_span,optional"`
+
+		Shadow *shadowHCL `hcl:"shadow,block"`
+	}
+
+	if diags := gohcl.DecodeBody(body, ctx, &tmp); diags.HasErrors() {
+		return nil, fmt.Errorf("error decoding HCL configuration: %w", diags)
+	}
+
+	var kind string
+	if diags := gohcl.DecodeExpression(tmp.Shape, ctx, &kind); diags.HasErrors() {
+		return nil, fmt.Errorf("error decoding HCL configuration: %w", diags)
+	}
+
+	if !isShapeKind(kind) {
+		return nil, fmt.Errorf("error decoding HCL configuration: %w", unknownShape(kind, tmp.Shape.Range()))
+	}
+
+	opts := []func(*jumble.Shape){
+		jumble.ShapeText(tmp.Text),
+		jumble.ShapeFontSize(tmp.FontSize),
+		jumble.ShapeMarkup(tmp.Markup),
+		jumble.ShapeShadow(tmp.Shadow.shadow()),
+	}
+
+	if tmp.Fill != nil {
+		opts = append(opts, jumble.ShapeFill(*tmp.Fill))
+	}
+
+	if tmp.Stroke != nil || tmp.StrokeWidth != nil {
+		color, width := "#000000", 1.5
+		if tmp.Stroke != nil {
+			color = *tmp.Stroke
+		}
+		if tmp.StrokeWidth != nil {
+			width = *tmp.StrokeWidth
+		}
+		opts = append(opts, jumble.ShapeStroke(color, width))
+	}
+
+	if tmp.TextColor != "" {
+		opts = append(opts, jumble.ShapeTextColor(tmp.TextColor))
+	}
+
+	if tmp.RowSpan != nil {
+		opts = append(opts, jumble.ShapeRowSpan(*tmp.RowSpan))
+	}
+
+	if tmp.ColSpan != nil {
+		opts = append(opts, jumble.ShapeColSpan(*tmp.ColSpan))
+	}
+
+	res := jumble.NewShape(tmp.Row, tmp.Col, kind, opts...)
+	return &res, nil
+}
+
+// connectors are the connector constructors by tile type
+var connectors = map[string]func(row, col int, opts ...func(*jumble.Connector)) jumble.Connector{
+	"cross":            jumble.CrossConnector,
+	"horizontal_line":  jumble.HorizontalConnector,
+	"vertical_line":    jumble.VerticalConnector,
+	"elbow_right_up":   jumble.ElbowRightUpConnector,
+	"elbow_right_down": jumble.ElbowRightDownConnector,
+	"elbow_left_down":  jumble.ElbowLeftDownConnector,
+	"elbow_left_up":    jumble.ElbowLeftUpConnector,
+	"tee_left":         jumble.TeeLeftConnector,
+	"tee_down":         jumble.TeeDownConnector,
+	"tee_right":        jumble.TeeRightConnector,
+	"tee_up":           jumble.TeeUpConnector,
+}
+
+// connectorDecoder returns the decoder of a connector type
+func connectorDecoder(kind string) TileDecoder {
+	return func(body hcl.Body, ctx *hcl.EvalContext) (jumble.Tile, error) {
+		return decodeConnector(body, ctx, kind)
+	}
 }
 
 // decodeConnectors decode all the HCL connector block
-func decodeConnector(body hcl.Body, ctx *hcl.EvalContext, kind string) (jumble.Connector, error) {
+func decodeConnector(body hcl.Body, ctx *hcl.EvalContext, kind string) (jumble.Tile, error) {
 	var tmp struct {
 		Row        int    `hcl:"row"`
 		Col        int    `hcl:"col"`
@@ -273,40 +937,15 @@ func decodeConnector(body hcl.Body, ctx *hcl.EvalContext, kind string) (jumble.C
 	}
 
 	if diags := gohcl.DecodeBody(body, ctx, &tmp); diags.HasErrors() {
-		return jumble.Connector{}, fmt.Errorf("error decoding HCL configuration: %w", diags)
-	}
-
-	var res jumble.Connector
-
-	switch kind {
-	case "cross":
-		res = jumble.CrossConnector(tmp.Row, tmp.Col)
-	case "horizontal_line":
-		res = jumble.HorizontalConnector(tmp.Row, tmp.Col)
-	case "vertical_line":
-		res = jumble.VerticalConnector(tmp.Row, tmp.Col)
-	case "elbow_right_up":
-		res = jumble.ElbowRightUpConnector(tmp.Row, tmp.Col)
-	case "elbow_right_down":
-		res = jumble.ElbowRightDownConnector(tmp.Row, tmp.Col)
-	case "elbow_left_down":
-		res = jumble.ElbowLeftDownConnector(tmp.Row, tmp.Col)
-	case "elbow_left_up":
-		res = jumble.ElbowLeftUpConnector(tmp.Row, tmp.Col)
-	case "tee_left":
-		res = jumble.TeeLeftConnector(tmp.Row, tmp.Col)
-	case "tee_down":
-		res = jumble.TeeDownConnector(tmp.Row, tmp.Col)
-	case "tee_right":
-		res = jumble.TeeRightConnector(tmp.Row, tmp.Col)
-	case "tee_up":
-		res = jumble.TeeUpConnector(tmp.Row, tmp.Col)
-	default:
-		return jumble.Connector{}, &unknowTileTypeError{
-			err: fmt.Errorf("unknown type: %s", kind),
-		}
+		return nil, fmt.Errorf("error decoding HCL configuration: %w", diags)
 	}
 
+	create, ok := connectors[kind]
+	if !ok {
+		return nil, fmt.Errorf("unknown connector type: %s", kind)
+	}
+	res := create(tmp.Row, tmp.Col)
+
 	if tmp.ArrowUp {
 		jumble.ConnectorArrowUp()(&res)
 	}
@@ -320,17 +959,5 @@ func decodeConnector(body hcl.Body, ctx *hcl.EvalContext, kind string) (jumble.C
 		jumble.ConnectorArrowLeft()(&res)
 	}
 
-	return res, nil
-}
-
-// unknowTileTypeError custom error
-//to identify unknown tile types
-type unknowTileTypeError struct {
-	err error
-}
-
-// Error implement the standard library
-// interface type for errors.
-func (e *unknowTileTypeError) Error() string {
-	return e.err.Error()
+	return &res, nil
 }
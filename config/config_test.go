@@ -1,7 +1,18 @@
 package config
 
 import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
 	"testing"
+
+	"github.com/hashicorp/hcl2/gohcl"
+	"github.com/hashicorp/hcl2/hcl"
+	"github.com/lucasepe/jumble"
 )
 
 func TestConfigParser(t *testing.T) {
@@ -38,3 +49,531 @@ tile "horizontal_line" "hl1" {
 	}
 
 }
+
+func TestFrameContains(t *testing.T) {
+	src := `
+rows = 10
+cols = 10
+
+tile "frame" "group" {
+	contains = ["a", "b"]
+}
+
+tile "label" "a" {
+	row = 2
+	col = 3
+	text = "a"
+}
+
+tile "label" "b" {
+	row = 5
+	col = 1
+	col_span = 4
+	text = "b"
+}
+
+tile "frame" "edge" {
+	contains = ["a"]
+	padding = 3
+}
+`
+	cfg, err := Decode([]byte(src), "frame.hcl")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	fr := cfg.Tiles["group"].(*jumble.Frame)
+	if got, want := []int{fr.Left, fr.Top, fr.Right, fr.Bottom}, []int{2, 1, 5, 4}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v want %v", got, want)
+	}
+
+	fr = cfg.Tiles["edge"].(*jumble.Frame)
+	if got, want := []int{fr.Left, fr.Top, fr.Right, fr.Bottom}, []int{0, 0, 5, 6}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v want %v", got, want)
+	}
+
+	_, err = Decode([]byte(`
+rows = 10
+cols = 10
+
+tile "frame" "group" {
+	contains = ["a", "missing"]
+}
+
+tile "label" "a" {
+	row = 2
+	col = 3
+	text = "a"
+}
+`), "frame.hcl")
+	if err == nil || !strings.Contains(err.Error(), "frame.hcl:6,13-29") || !strings.Contains(err.Error(), "missing") {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestTileReferences(t *testing.T) {
+	src := `
+rows = 10
+cols = 10
+
+tile "label" "b" {
+	row = below("a", 1)
+	col = right_of("group", 1)
+	text = "b"
+}
+
+tile "frame" "group" {
+	contains = ["a"]
+}
+
+tile "label" "a" {
+	row = 2
+	col = 3
+	text = "a"
+}
+`
+	cfg, err := Decode([]byte(src), "refs.hcl")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	row, col := cfg.Tiles["b"].Location()
+	if got, want := []int{row, col}, []int{3, 4}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v want %v", got, want)
+	}
+
+	// the frames first, then the declaration order
+	if got, want := cfg.Order, []string{"group", "b", "a"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v want %v", got, want)
+	}
+
+	_, err = Decode([]byte(`
+rows = 10
+cols = 10
+
+tile "label" "a" {
+	row = row("c")
+	col = 1
+	text = "a"
+}
+
+tile "label" "b" {
+	row = 1
+	col = col("a")
+	text = "b"
+}
+
+tile "label" "c" {
+	row = 1
+	col = 1
+	text = "${row("b")}"
+}
+`), "cycle.hcl")
+	var diags hcl.Diagnostics
+	if !errors.As(err, &diags) {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	got := []string{}
+	for _, d := range diags {
+		got = append(got, d.Subject.String())
+	}
+	want := []string{"cycle.hcl:6,12-15", "cycle.hcl:20,16-19", "cycle.hcl:13,12-15"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v want %v", got, want)
+	}
+
+	if !strings.Contains(diags[0].Detail, "a -> c -> b -> a") {
+		t.Errorf("unexpected detail: %s", diags[0].Detail)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	src := `
+rows = 4
+cols = 4
+
+tile "icon" "a" {
+	row = 1
+	col = 1
+	uri = "assets://aws_missing_icon"
+}
+
+tile "label" "a" {
+	row = 2
+	col = 0
+	text = "a"
+}
+
+tile "label" "b" {
+	row = 3
+	col = 3
+	col_span = 2
+	text = "b"
+}
+
+tile "frame" "f" {
+	left = 3
+	top = 0
+	right = 1
+	bottom = 2
+}
+
+tile "icon" "c" {
+	row = 0
+	col = 0
+	uri = "assets://aws_missing_icon"
+}
+
+tile "horizontal_line" "" {
+	row = 2
+	col = 0
+}
+`
+	diags, files := Validate([]byte(src), "validate.hcl")
+	if files["validate.hcl"] == nil {
+		t.Error("missing source file")
+	}
+
+	got := []string{}
+	for _, d := range diags {
+		got = append(got, fmt.Sprintf("%s %d:%d", d.Summary, d.Subject.Start.Line, d.Subject.Start.Column))
+	}
+
+	want := []string{
+		"Duplicate tile ID 11:14",
+		"Unknown asset 5:1",
+		"Tile out of bounds 17:1",
+		"Reversed frame 24:1",
+		"Unknown asset 31:1",
+		"Overlapping tiles 37:1",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v want %v", got, want)
+	}
+
+	if diags[5].Severity != hcl.DiagWarning {
+		t.Errorf("overlapping tiles should be a warning")
+	}
+
+	// the tiles with the same ID are not overwritten
+	_, err := Decode([]byte(src), "validate.hcl")
+	var diag *hcl.Diagnostic
+	if !errors.As(err, &diag) || diag.Summary != "Duplicate tile ID" {
+		t.Errorf("unexpected error: %v", err)
+	}
+
+	diags, _ = Validate([]byte("rows = 4\ncols = \n"), "broken.hcl")
+	if !diags.HasErrors() {
+		t.Error("succeeded; want error")
+	}
+}
+
+func TestUnknownTileType(t *testing.T) {
+	src := `
+rows = 4
+cols = 4
+
+tile "elbow_rigth_up" "x" {
+	row = 1
+	col = 1
+}
+
+tile "label" "l" {
+	row = 0
+	col = 0
+	text = "l"
+	colour = "#ff0000"
+}
+`
+	_, err := Decode([]byte(src), "strict.hcl")
+	if err == nil || !strings.Contains(err.Error(), `Did you mean "elbow_right_up"?`) {
+		t.Errorf("unexpected error: %v", err)
+	}
+
+	cfg, err := Decode([]byte(src), "strict.hcl", Lenient(true))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, ok := cfg.Tiles["l"]; !ok || len(cfg.Tiles) != 1 {
+		t.Errorf("unexpected tiles: %v", cfg.Tiles)
+	}
+
+	diags, _ := Validate([]byte(src), "strict.hcl", Lenient(true))
+	if len(diags) != 1 || diags[0].Severity != hcl.DiagWarning || diags[0].Summary != "Unknown tile type" {
+		t.Errorf("unexpected diagnostics: %v", diags)
+	}
+}
+
+func TestUnknownShape(t *testing.T) {
+	src := `
+rows = 2
+cols = 2
+
+tile "shape" "db" {
+	row = 0
+	col = 0
+	shape = "cilinder"
+}
+`
+	_, err := Decode([]byte(src), "shape.hcl")
+
+	var diag *hcl.Diagnostic
+	if !errors.As(err, &diag) {
+		t.Fatalf("expected a diagnostic, got: %v", err)
+	}
+
+	if diag.Summary != "Unknown shape" || !strings.Contains(diag.Detail, `Did you mean "cylinder"?`) {
+		t.Errorf("unexpected diagnostic: %v", diag)
+	}
+
+	if diag.Subject == nil || diag.Subject.Start.Line != 8 {
+		t.Errorf("unexpected subject: %v", diag.Subject)
+	}
+}
+
+func TestRegisterTileType(t *testing.T) {
+	err := RegisterTileType("test_pod", func(body hcl.Body, ctx *hcl.EvalContext) (jumble.Tile, error) {
+		var tmp struct {
+			Row  int    `hcl:"row"`
+			Col  int    `hcl:"col"`
+			Name string `hcl:"name"`
+		}
+
+		if diags := gohcl.DecodeBody(body, ctx, &tmp); diags.HasErrors() {
+			return nil, diags
+		}
+
+		lab := jumble.NewLabel(tmp.Row, tmp.Col, tmp.Name)
+		return &lab, nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cfg, err := Decode([]byte(`
+rows = 4
+cols = 4
+
+tile "test_pod" "api" {
+	row = 1
+	col = right_of("db", 1)
+	name = "api"
+}
+
+tile "icon" "db" {
+	row = 1
+	col = 0
+	uri = "assets://aws_rds"
+}
+`), "pod.hcl")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	row, col := cfg.Tiles["api"].Location()
+	if got, want := []int{row, col}, []int{1, 1}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v want %v", got, want)
+	}
+
+	if err := RegisterTileType(" ", nil); err == nil {
+		t.Error("succeeded; want error")
+	}
+}
+
+func TestModules(t *testing.T) {
+	dir, err := ioutil.TempDir("", "jumble-modules")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	files := map[string]string{
+		"common.hcl": `
+tile "label" "title" {
+	row = 0
+	col = 0
+	text = "title"
+}
+`,
+		"cluster.hcl": `
+rows = 3
+cols = 3
+
+var "name" {
+	value = "api"
+}
+
+tile "icon" "agw" {
+	row = 1
+	col = 0
+	uri = "assets://aws_api_gateway"
+}
+
+tile "label" "caption" {
+	row = below("agw", 1)
+	col = col("agw")
+	text = var.name
+}
+`,
+		"main.hcl": `
+rows = 10
+cols = 10
+
+include "common.hcl" {}
+
+tile "label" "note" {
+	row = row("module.api.agw")
+	col = right_of("module.api.agw", 1)
+	text = "note"
+}
+
+module "api" {
+	source = "./cluster.hcl"
+	row = 3
+	col = 2
+	name = "orders"
+}
+`,
+		"unknown.hcl": `
+rows = 10
+cols = 10
+
+module "api" {
+	source = "cluster.hcl"
+	size = 2
+}
+`,
+		"cycle.hcl": `
+rows = 10
+cols = 10
+
+module "self" {
+	source = "cycle.hcl"
+}
+`,
+		"overlap.hcl": `
+rows = 10
+cols = 10
+
+include "common.hcl" {}
+
+tile "label" "intro" {
+	row = 0
+	col = 0
+	text = "intro"
+}
+`,
+	}
+
+	for name, src := range files {
+		if err := ioutil.WriteFile(filepath.Join(dir, name), []byte(src), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	cfg, err := DecodeURI(filepath.Join(dir, "main.hcl"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := map[string][]int{
+		"title":              {0, 0},
+		"note":               {4, 3},
+		"module.api.agw":     {4, 2},
+		"module.api.caption": {5, 2},
+	}
+
+	got := map[string][]int{}
+	for id, el := range cfg.Tiles {
+		row, col := el.Location()
+		got[id] = []int{row, col}
+	}
+
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v want %v", got, want)
+	}
+
+	order := []string{"note", "title", "module.api.agw", "module.api.caption"}
+	if !reflect.DeepEqual(cfg.Order, order) {
+		t.Errorf("got %v want %v", cfg.Order, order)
+	}
+
+	_, err = DecodeURI(filepath.Join(dir, "unknown.hcl"))
+	if err == nil || !strings.Contains(err.Error(), "no variable named 'size'") {
+		t.Errorf("unexpected error: %v", err)
+	}
+
+	_, err = DecodeURI(filepath.Join(dir, "cycle.hcl"))
+	if err == nil || !strings.Contains(err.Error(), "Module cycle") {
+		t.Errorf("unexpected error: %v", err)
+	}
+	// the module files are returned for the source snippets
+	diags, parsed, err := ValidateURI(filepath.Join(dir, "unknown.hcl"))
+	if err != nil || !diags.HasErrors() {
+		t.Fatalf("unexpected result: %v %v", diags, err)
+	}
+	if parsed[filepath.Join(dir, "cluster.hcl")] == nil {
+		t.Error("missing module source file")
+	}
+
+	// the included tiles are declared after the including file ones
+	diags, _, err = ValidateURI(filepath.Join(dir, "overlap.hcl"))
+	if err != nil || len(diags) != 1 || diags[0].Subject.Filename != filepath.Join(dir, "common.hcl") {
+		t.Errorf("unexpected result: %v %v", diags, err)
+	}
+
+	diags, _ = Validate([]byte("rows = 1\ncols = 1\ninclude \"common.hcl\"\n"), "include.hcl")
+	if !diags.HasErrors() || !strings.Contains(diags[0].Detail, `include "common.hcl" {}`) {
+		t.Errorf("unexpected diagnostics: %v", diags)
+	}
+}
+
+func TestAssetPackPath(t *testing.T) {
+	dir, err := ioutil.TempDir("", "jumble-assets")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	if err := os.Mkdir(filepath.Join(dir, "icons"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(filepath.Join(dir, "icons", "gear.png"), []byte("png"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	src := `
+rows = 2
+cols = 2
+
+asset_pack "relpack" {
+	path = "./icons"
+}
+`
+	if err := ioutil.WriteFile(filepath.Join(dir, "main.hcl"), []byte(src), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	// decode from another working directory
+	cwd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Chdir(cwd)
+
+	if err := os.Chdir(os.TempDir()); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := DecodeURI(filepath.Join(dir, "main.hcl")); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	file, err := jumble.OpenAsset("assets://relpack_gear")
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	file.Close()
+}
@@ -0,0 +1,216 @@
+package funcs
+
+import (
+	"fmt"
+
+	"github.com/lucasepe/jumble"
+	"github.com/zclconf/go-cty/cty"
+	"github.com/zclconf/go-cty/cty/function"
+	"github.com/zclconf/go-cty/cty/gocty"
+)
+
+// cellType is the type of the functions returning a cell.
+var cellType = cty.Object(map[string]cty.Type{
+	"row": cty.Number,
+	"col": cty.Number,
+})
+
+// RightOfFunc returns the column n cells after the right edge of a tile.
+var RightOfFunc = func(tiles map[string]jumble.Tile) function.Function {
+	return offsetFunc(tiles, func(b bounds, n int) int {
+		return b.col + b.cols - 1 + n
+	})
+}
+
+// LeftOfFunc returns the column n cells before the left edge of a tile.
+var LeftOfFunc = func(tiles map[string]jumble.Tile) function.Function {
+	return offsetFunc(tiles, func(b bounds, n int) int {
+		return b.col - n
+	})
+}
+
+// BelowFunc returns the row n cells after the bottom edge of a tile.
+var BelowFunc = func(tiles map[string]jumble.Tile) function.Function {
+	return offsetFunc(tiles, func(b bounds, n int) int {
+		return b.row + b.rows - 1 + n
+	})
+}
+
+// AboveFunc returns the row n cells before the top edge of a tile.
+var AboveFunc = func(tiles map[string]jumble.Tile) function.Function {
+	return offsetFunc(tiles, func(b bounds, n int) int {
+		return b.row - n
+	})
+}
+
+// AlignRowFunc returns the middle row of a tile
+// (the tile row unless it spans several rows).
+var AlignRowFunc = func(tiles map[string]jumble.Tile) function.Function {
+	return function.New(&function.Spec{
+		Params: []function.Parameter{
+			{
+				Name: "origin",
+				Type: cty.String,
+			},
+		},
+		Type: function.StaticReturnType(cty.Number),
+		Impl: func(args []cty.Value, retType cty.Type) (ret cty.Value, err error) {
+			b, err := tileBounds(tiles, args[0])
+			if err != nil {
+				return cty.NumberIntVal(-1), err
+			}
+
+			row, _ := b.center()
+			return cty.NumberIntVal(int64(row)), nil
+		},
+	})
+}
+
+// AlignColFunc returns the middle column of a tile
+// (the tile column unless it spans several columns).
+var AlignColFunc = func(tiles map[string]jumble.Tile) function.Function {
+	return function.New(&function.Spec{
+		Params: []function.Parameter{
+			{
+				Name: "origin",
+				Type: cty.String,
+			},
+		},
+		Type: function.StaticReturnType(cty.Number),
+		Impl: func(args []cty.Value, retType cty.Type) (ret cty.Value, err error) {
+			b, err := tileBounds(tiles, args[0])
+			if err != nil {
+				return cty.NumberIntVal(-1), err
+			}
+
+			_, col := b.center()
+			return cty.NumberIntVal(int64(col)), nil
+		},
+	})
+}
+
+// CenterOfFunc returns the middle cell of a tile
+// as an object with 'row' and 'col' attributes.
+var CenterOfFunc = func(tiles map[string]jumble.Tile) function.Function {
+	return function.New(&function.Spec{
+		Params: []function.Parameter{
+			{
+				Name: "origin",
+				Type: cty.String,
+			},
+		},
+		Type: function.StaticReturnType(cellType),
+		Impl: func(args []cty.Value, retType cty.Type) (ret cty.Value, err error) {
+			b, err := tileBounds(tiles, args[0])
+			if err != nil {
+				return cty.NullVal(cellType), err
+			}
+
+			return cellVal(b.center()), nil
+		},
+	})
+}
+
+// BetweenFunc returns the cell halfway between the middle cells
+// of two tiles as an object with 'row' and 'col' attributes.
+var BetweenFunc = func(tiles map[string]jumble.Tile) function.Function {
+	return function.New(&function.Spec{
+		Params: []function.Parameter{
+			{
+				Name: "from",
+				Type: cty.String,
+			},
+			{
+				Name: "to",
+				Type: cty.String,
+			},
+		},
+		Type: function.StaticReturnType(cellType),
+		Impl: func(args []cty.Value, retType cty.Type) (ret cty.Value, err error) {
+			a, err := tileBounds(tiles, args[0])
+			if err != nil {
+				return cty.NullVal(cellType), err
+			}
+
+			b, err := tileBounds(tiles, args[1])
+			if err != nil {
+				return cty.NullVal(cellType), err
+			}
+
+			r1, c1 := a.center()
+			r2, c2 := b.center()
+			return cellVal((r1+r2)/2, (c1+c2)/2), nil
+		},
+	})
+}
+
+// bounds are the cells covered by a tile.
+type bounds struct {
+	row, col   int
+	rows, cols int
+}
+
+// center returns the middle cell.
+func (b bounds) center() (int, int) {
+	return b.row + (b.rows-1)/2, b.col + (b.cols-1)/2
+}
+
+// tileBounds returns the cells covered by the tile with the given id.
+func tileBounds(tiles map[string]jumble.Tile, id cty.Value) (bounds, error) {
+	var origin string
+	if err := gocty.FromCtyValue(id, &origin); err != nil {
+		return bounds{}, err
+	}
+
+	t, ok := tiles[origin]
+	if !ok {
+		return bounds{}, fmt.Errorf("tile (ID: %s) not found", origin)
+	}
+
+	res := bounds{rows: 1, cols: 1}
+	res.row, res.col = t.Location()
+	if sp, ok := t.(jumble.Spanner); ok {
+		res.rows, res.cols = sp.Span()
+	}
+
+	return res, nil
+}
+
+// offsetFunc returns a function of a tile id and a number
+// of cells; fn computes the result from the tile bounds.
+func offsetFunc(tiles map[string]jumble.Tile, fn func(b bounds, n int) int) function.Function {
+	return function.New(&function.Spec{
+		Params: []function.Parameter{
+			{
+				Name: "origin",
+				Type: cty.String,
+			},
+			{
+				Name: "steps",
+				Type: cty.Number,
+			},
+		},
+		Type: function.StaticReturnType(cty.Number),
+		Impl: func(args []cty.Value, retType cty.Type) (ret cty.Value, err error) {
+			b, err := tileBounds(tiles, args[0])
+			if err != nil {
+				return cty.NumberIntVal(-1), err
+			}
+
+			var steps int
+			if err := gocty.FromCtyValue(args[1], &steps); err != nil {
+				return cty.NumberIntVal(-1), err
+			}
+
+			return cty.NumberIntVal(int64(fn(b, steps))), nil
+		},
+	})
+}
+
+// cellVal returns the object value of a cell.
+func cellVal(row, col int) cty.Value {
+	return cty.ObjectVal(map[string]cty.Value{
+		"row": cty.NumberIntVal(int64(row)),
+		"col": cty.NumberIntVal(int64(col)),
+	})
+}
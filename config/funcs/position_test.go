@@ -0,0 +1,52 @@
+package funcs
+
+import (
+	"testing"
+
+	"github.com/lucasepe/jumble"
+	"github.com/zclconf/go-cty/cty"
+	"github.com/zclconf/go-cty/cty/function"
+)
+
+func TestPositionFuncs(t *testing.T) {
+	a := jumble.NewLabel(2, 3, "a")
+	b := jumble.NewLabel(6, 1, "b", jumble.LabelRowSpan(3), jumble.LabelColSpan(5))
+	tiles := map[string]jumble.Tile{"a": &a, "b": &b}
+
+	id := func(s string) cty.Value { return cty.StringVal(s) }
+	num := func(n int64) cty.Value { return cty.NumberIntVal(n) }
+
+	tests := []struct {
+		name string
+		fn   function.Function
+		args []cty.Value
+		want cty.Value
+	}{
+		{"right_of(a, 2)", RightOfFunc(tiles), []cty.Value{id("a"), num(2)}, num(5)},
+		{"right_of(b, 1)", RightOfFunc(tiles), []cty.Value{id("b"), num(1)}, num(6)},
+		{"left_of(a, 1)", LeftOfFunc(tiles), []cty.Value{id("a"), num(1)}, num(2)},
+		{"below(b, 1)", BelowFunc(tiles), []cty.Value{id("b"), num(1)}, num(9)},
+		{"above(a, 2)", AboveFunc(tiles), []cty.Value{id("a"), num(2)}, num(0)},
+		{"align_row(b)", AlignRowFunc(tiles), []cty.Value{id("b")}, num(7)},
+		{"align_col(b)", AlignColFunc(tiles), []cty.Value{id("b")}, num(3)},
+		{"center_of(a)", CenterOfFunc(tiles), []cty.Value{id("a")}, cellVal(2, 3)},
+		{"between(a, b)", BetweenFunc(tiles), []cty.Value{id("a"), id("b")}, cellVal(4, 3)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.fn.Call(tt.args)
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+
+			if !got.RawEquals(tt.want) {
+				t.Errorf("wrong result\ngot:  %#v\nwant: %#v", got, tt.want)
+			}
+		})
+	}
+
+	if _, err := BelowFunc(tiles).Call([]cty.Value{id("missing"), num(1)}); err == nil {
+		t.Error("succeeded; want error")
+	}
+}
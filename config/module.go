@@ -0,0 +1,247 @@
+package config
+
+import (
+	"fmt"
+	"net/url"
+	"path/filepath"
+	"strings"
+
+	"github.com/hashicorp/hcl2/hcl"
+	"github.com/hashicorp/hcl2/hclparse"
+	"github.com/lucasepe/jumble"
+	"github.com/zclconf/go-cty/cty"
+	"github.com/zclconf/go-cty/cty/gocty"
+
+	"github.com/lucasepe/jumble/fetch"
+)
+
+// moduleHCL is the HCL 'module' block.
+type moduleHCL struct {
+	Name    string   `hcl:"name,label"`
+	HCLBody hcl.Body `hcl:",remain"`
+}
+
+// moduleCall holds the parameters of
+// the module block instantiating a file.
+type moduleCall struct {
+	name   string
+	params map[string]*hcl.Attribute
+	values map[string]cty.Value
+	// chain are the files being decoded
+	// (to detect the modules cycles)
+	chain []string
+}
+
+// includeSchema is the schema of the 'include' blocks.
+var includeSchema = &hcl.BodySchema{
+	Blocks: []hcl.BlockHeaderSchema{
+		{Type: "include", LabelNames: []string{"path"}},
+	},
+}
+
+// moduleID returns the ID of a module block.
+func moduleID(name string) string {
+	return "module." + name
+}
+
+// moduleOf returns the ID of the module block
+// defining a tile (e.g. 'module.api' for the tile
+// 'module.api.agw') or an empty string.
+func moduleOf(id string) string {
+	parts := strings.SplitN(id, ".", 3)
+	if len(parts) < 3 || parts[0] != "module" {
+		return ""
+	}
+
+	return moduleID(parts[1])
+}
+
+// resolveURI returns the uri of ref relative to the base one.
+func resolveURI(base, ref string) string {
+	if strings.Contains(ref, "://") || filepath.IsAbs(ref) {
+		return ref
+	}
+
+	if strings.HasPrefix(base, "http") {
+		if u, err := url.Parse(base); err == nil {
+			if r, err := url.Parse(ref); err == nil {
+				return u.ResolveReference(r).String()
+			}
+		}
+	}
+
+	return filepath.Join(filepath.Dir(base), ref)
+}
+
+// parseHCL parses an HCL file; the parse errors on
+// 'include' lines explain the include block syntax.
+func parseHCL(parser *hclparse.Parser, data []byte, uri string) (*hcl.File, hcl.Diagnostics) {
+	file, diags := parser.ParseHCL(data, uri)
+
+	lines := strings.Split(string(data), "\n")
+	for _, diag := range diags {
+		if diag.Subject == nil || diag.Subject.Start.Line < 1 || diag.Subject.Start.Line > len(lines) {
+			continue
+		}
+
+		fields := strings.Fields(lines[diag.Subject.Start.Line-1])
+		if len(fields) > 0 && fields[0] == "include" {
+			diag.Detail += ` An include is a block with an empty body: include "common.hcl" {}.`
+		}
+	}
+
+	return file, diags
+}
+
+// loadIncludes returns the given file and all the files it
+// includes (also indirectly); each file is included once.
+func loadIncludes(parser *hclparse.Parser, file *hcl.File, uri string) ([]*hcl.File, error) {
+	res, uris := []*hcl.File{file}, []string{uri}
+	seen := map[string]bool{uri: true}
+
+	for i := 0; i < len(res); i++ {
+		content, _, diags := res[i].Body.PartialContent(includeSchema)
+		if diags.HasErrors() {
+			return nil, fmt.Errorf("error decoding HCL configuration: %w", diags)
+		}
+
+		for _, blk := range content.Blocks {
+			inc := resolveURI(uris[i], blk.Labels[0])
+			if seen[inc] {
+				continue
+			}
+			seen[inc] = true
+
+			data, err := fetch.Default.Config(inc)
+			if err != nil {
+				return nil, fmt.Errorf("error decoding HCL configuration: %w", &hcl.Diagnostic{
+					Severity: hcl.DiagError,
+					Summary:  "Invalid include",
+					Detail:   fmt.Sprintf("The file '%s' can't be included: %s.", inc, err),
+					Subject:  blk.LabelRanges[0].Ptr(),
+				})
+			}
+
+			f, diags := parseHCL(parser, data, inc)
+			if diags.HasErrors() {
+				return nil, fmt.Errorf("error parsing HCL file: %w", diags)
+			}
+
+			res, uris = append(res, f), append(uris, inc)
+		}
+	}
+
+	return res, nil
+}
+
+// bind sets the module parameters as
+// values of the declared variables.
+func (mc *moduleCall) bind(variables map[string]cty.Value) error {
+	for name, val := range mc.values {
+		if _, ok := variables[name]; !ok {
+			return fmt.Errorf("error decoding HCL configuration: %w", &hcl.Diagnostic{
+				Severity: hcl.DiagError,
+				Summary:  "Unsupported module parameter",
+				Detail:   fmt.Sprintf("The module '%s' has no variable named '%s'.", mc.name, name),
+				Subject:  mc.params[name].NameRange.Ptr(),
+			})
+		}
+
+		variables[name] = val
+	}
+
+	return nil
+}
+
+// decodeModule decodes the diagram file instantiated by a module
+// block and adds its tiles (moved by the module row and col) with
+// the IDs prefixed by 'module.<name>.'; chain are the files being
+// decoded. The IDs of the added tiles are returned in order.
+func (cfg *Config) decodeModule(parser *hclparse.Parser, name string, body hcl.Body, ctx *hcl.EvalContext, options DecodeOptions, chain []string) ([]string, error) {
+	attrs, diags := body.JustAttributes()
+	if diags.HasErrors() {
+		return nil, fmt.Errorf("error decoding HCL configuration: %w", diags)
+	}
+
+	call := &moduleCall{
+		name:   name,
+		params: map[string]*hcl.Attribute{},
+		values: map[string]cty.Value{},
+		chain:  chain,
+	}
+
+	var source string
+	var row, col int
+	for key, attr := range attrs {
+		val, diags := attr.Expr.Value(ctx)
+		if diags.HasErrors() {
+			return nil, fmt.Errorf("error decoding HCL configuration: %w", diags)
+		}
+
+		var err error
+		switch key {
+		case "source":
+			err = gocty.FromCtyValue(val, &source)
+			source = resolveURI(attr.Range.Filename, source)
+		case "row":
+			err = gocty.FromCtyValue(val, &row)
+		case "col":
+			err = gocty.FromCtyValue(val, &col)
+		default:
+			call.params[key], call.values[key] = attr, val
+		}
+
+		if err != nil {
+			return nil, fmt.Errorf("error decoding HCL configuration: %s: %s: %w", attr.Range, key, err)
+		}
+	}
+
+	if _, ok := attrs["source"]; !ok {
+		return nil, fmt.Errorf("error decoding HCL configuration: %w", &hcl.Diagnostic{
+			Severity: hcl.DiagError,
+			Summary:  "Missing module source",
+			Detail:   fmt.Sprintf("The module '%s' needs the 'source' diagram file.", name),
+			Subject:  body.MissingItemRange().Ptr(),
+		})
+	}
+
+	for _, el := range chain {
+		if el == source {
+			return nil, fmt.Errorf("error decoding HCL configuration: %w", &hcl.Diagnostic{
+				Severity: hcl.DiagError,
+				Summary:  "Module cycle",
+				Detail:   fmt.Sprintf("The module '%s' instantiates '%s' which is already being decoded.", name, source),
+				Subject:  attrs["source"].Expr.Range().Ptr(),
+			})
+		}
+	}
+
+	data, err := fetch.Default.Config(source)
+	if err != nil {
+		return nil, fmt.Errorf("error loading module '%s': %w", name, err)
+	}
+
+	sub, err := decode(parser, data, source, options, call)
+	if err != nil {
+		return nil, fmt.Errorf("error loading module '%s': %w", name, err)
+	}
+
+	prefix := moduleID(name) + "."
+	for _, el := range sub.decoded {
+		mv, ok := el.tile.(jumble.Mover)
+		if !ok {
+			return nil, fmt.Errorf("error loading module '%s': the %s can't be moved", name, el.block.name(el.id))
+		}
+		mv.Move(row, col)
+
+		cfg.decoded = append(cfg.decoded, decodedTile{id: prefix + el.id, tile: el.tile, block: el.block})
+	}
+
+	ids := make([]string, 0, len(sub.Order))
+	for _, id := range sub.Order {
+		cfg.Tiles[prefix+id] = sub.Tiles[id]
+		ids = append(ids, prefix+id)
+	}
+
+	return ids, nil
+}
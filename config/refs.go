@@ -0,0 +1,219 @@
+package config
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/hashicorp/hcl2/hcl"
+	"github.com/hashicorp/hcl2/hcl/hclsyntax"
+	"github.com/zclconf/go-cty/cty"
+)
+
+// tileRefArgs are the functions referencing tiles
+// with the indexes of their tile ID arguments.
+var tileRefArgs = map[string][]int{
+	"row":       {0},
+	"col":       {0},
+	"move":      {0},
+	"right_of":  {0},
+	"left_of":   {0},
+	"below":     {0},
+	"above":     {0},
+	"align_row": {0},
+	"align_col": {0},
+	"center_of": {0},
+	"between":   {0, 1},
+}
+
+// tileRef is a reference to a tile (by ID).
+type tileRef struct {
+	id  string
+	rng hcl.Range
+}
+
+// tileRefs returns the tiles referenced in the body of a tile
+// block: the ID arguments of the positioning functions and
+// the IDs listed by the 'contains' attribute of the frames.
+func tileRefs(body hcl.Body, ctx *hcl.EvalContext) []tileRef {
+	sb, ok := body.(*hclsyntax.Body)
+	if !ok {
+		return nil
+	}
+
+	var res []tileRef
+	for name, attr := range sb.Attributes {
+		if name == "contains" {
+			res = append(res, containsRefs(attr.Expr, ctx)...)
+			continue
+		}
+
+		hclsyntax.VisitAll(attr.Expr, func(node hclsyntax.Node) hcl.Diagnostics {
+			call, ok := node.(*hclsyntax.FunctionCallExpr)
+			if !ok {
+				return nil
+			}
+
+			for _, i := range tileRefArgs[call.Name] {
+				if i >= len(call.Args) {
+					continue
+				}
+
+				if id, ok := stringValue(call.Args[i], ctx); ok {
+					res = append(res, tileRef{id: id, rng: call.Args[i].Range()})
+				}
+			}
+			return nil
+		})
+	}
+
+	for _, blk := range sb.Blocks {
+		res = append(res, tileRefs(blk.Body, ctx)...)
+	}
+
+	return res
+}
+
+// containsRefs returns the IDs of a 'contains' list.
+func containsRefs(expr hcl.Expression, ctx *hcl.EvalContext) []tileRef {
+	val, diags := expr.Value(ctx)
+	if diags.HasErrors() || !val.IsWhollyKnown() || val.IsNull() || !val.CanIterateElements() {
+		return nil
+	}
+
+	var res []tileRef
+	for it := val.ElementIterator(); it.Next(); {
+		_, el := it.Element()
+		if el.IsNull() || el.Type() != cty.String {
+			continue
+		}
+		res = append(res, tileRef{id: el.AsString(), rng: expr.Range()})
+	}
+
+	return res
+}
+
+// stringValue returns the value of a
+// string expression (when it is known).
+func stringValue(expr hcl.Expression, ctx *hcl.EvalContext) (string, bool) {
+	val, diags := expr.Value(ctx)
+	if diags.HasErrors() || !val.IsKnown() || val.IsNull() || val.Type() != cty.String {
+		return "", false
+	}
+
+	return val.AsString(), true
+}
+
+// sortTiles returns the tile blocks sorted so that every tile
+// comes after the tiles it references (the declaration order
+// is kept otherwise); a reference cycle is reported with
+// the source range of each reference in it.
+func sortTiles(tiles []*tileHCL, ctx *hcl.EvalContext) ([]*tileHCL, hcl.Diagnostics) {
+	index := make(map[string]int, len(tiles))
+	refs := make([][]tileRef, len(tiles))
+	for i, el := range tiles {
+		index[el.ID] = i
+		refs[i] = tileRefs(el.HCLBody, ctx)
+	}
+
+	const (
+		unvisited = iota
+		visiting
+		visited
+	)
+
+	state := make([]int, len(tiles))
+	stack := []int{}
+	res := make([]*tileHCL, 0, len(tiles))
+
+	var visit func(i int) hcl.Diagnostics
+	visit = func(i int) hcl.Diagnostics {
+		state[i] = visiting
+		stack = append(stack, i)
+
+		for _, ref := range refs[i] {
+			j, ok := refIndex(index, ref.id)
+			if !ok {
+				// unknown tiles are reported on evaluation
+				continue
+			}
+
+			switch state[j] {
+			case visiting:
+				for k := range stack {
+					if stack[k] == j {
+						return cycleDiags(tiles, refs, index, stack[k:])
+					}
+				}
+			case unvisited:
+				if diags := visit(j); diags.HasErrors() {
+					return diags
+				}
+			}
+		}
+
+		stack = stack[:len(stack)-1]
+		state[i] = visited
+		res = append(res, tiles[i])
+		return nil
+	}
+
+	for i := range tiles {
+		if state[i] != unvisited {
+			continue
+		}
+
+		if diags := visit(i); diags.HasErrors() {
+			return nil, diags
+		}
+	}
+
+	return res, nil
+}
+
+// refIndex returns the index of the block defining
+// a tile (the module block for the module tiles).
+func refIndex(index map[string]int, id string) (int, bool) {
+	if j, ok := index[id]; ok {
+		return j, true
+	}
+
+	if mod := moduleOf(id); mod != "" {
+		j, ok := index[mod]
+		return j, ok
+	}
+
+	return 0, false
+}
+
+// cycleDiags reports a reference cycle (the indexes of the tiles
+// in it); there is a diagnostic for each reference in the cycle.
+func cycleDiags(tiles []*tileHCL, refs [][]tileRef, index map[string]int, cycle []int) hcl.Diagnostics {
+	ids := make([]string, 0, len(cycle)+1)
+	for _, i := range cycle {
+		ids = append(ids, tiles[i].ID)
+	}
+	ids = append(ids, tiles[cycle[0]].ID)
+	path := strings.Join(ids, " -> ")
+
+	var diags hcl.Diagnostics
+	for k, i := range cycle {
+		next := cycle[(k+1)%len(cycle)]
+		for _, ref := range refs[i] {
+			if j, ok := refIndex(index, ref.id); !ok || j != next {
+				continue
+			}
+
+			rng := ref.rng
+			diags = append(diags, &hcl.Diagnostic{
+				Severity: hcl.DiagError,
+				Summary:  "Tile reference cycle",
+				Detail: fmt.Sprintf("The tile '%s' references the tile '%s' in the cycle %s.",
+					tiles[i].ID, tiles[next].ID, path),
+				Subject: &rng,
+			})
+			break
+		}
+	}
+
+	return diags
+}
@@ -0,0 +1,72 @@
+package config
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/hashicorp/hcl2/hcl"
+	"github.com/lucasepe/jumble"
+)
+
+// TileDecoder decodes the HCL body of a tile block.
+type TileDecoder func(body hcl.Body, ctx *hcl.EvalContext) (jumble.Tile, error)
+
+var (
+	tileTypesMu sync.RWMutex
+	tileTypes   = map[string]TileDecoder{}
+)
+
+func init() {
+	tileTypes["icon"] = decodeIcon
+	tileTypes["label"] = decodeLabel
+	tileTypes["shape"] = decodeShape
+	tileTypes["frame"] = decodeFrame
+
+	for kind := range connectors {
+		tileTypes[kind] = connectorDecoder(kind)
+	}
+}
+
+// RegisterTileType registers the decoder of the tile blocks of
+// the specified type (e.g. `tile "k8s_pod" "api" { ... }`);
+// the decoder of a built-in type can be replaced as well.
+func RegisterTileType(kind string, decoder TileDecoder) error {
+	if strings.TrimSpace(kind) == "" {
+		return fmt.Errorf("tile type can't be empty")
+	}
+
+	if decoder == nil {
+		return fmt.Errorf("tile type '%s' has no decoder", kind)
+	}
+
+	tileTypesMu.Lock()
+	tileTypes[kind] = decoder
+	tileTypesMu.Unlock()
+
+	return nil
+}
+
+// TileTypes returns all the registered tile types.
+func TileTypes() []string {
+	tileTypesMu.RLock()
+	defer tileTypesMu.RUnlock()
+
+	res := make([]string, 0, len(tileTypes))
+	for kind := range tileTypes {
+		res = append(res, kind)
+	}
+	sort.Strings(res)
+
+	return res
+}
+
+// tileDecoder returns the decoder of a tile type.
+func tileDecoder(kind string) (TileDecoder, bool) {
+	tileTypesMu.RLock()
+	defer tileTypesMu.RUnlock()
+
+	res, ok := tileTypes[kind]
+	return res, ok
+}
@@ -0,0 +1,90 @@
+package config
+
+import (
+	"fmt"
+
+	"github.com/agext/levenshtein"
+	"github.com/hashicorp/hcl2/hcl"
+	"github.com/lucasepe/jumble"
+)
+
+// isTileKind checks if the tile type is registered.
+func isTileKind(kind string) bool {
+	_, ok := tileDecoder(kind)
+	return ok
+}
+
+// unknownTileType reports an unknown tile type
+// suggesting the most similar known one.
+func unknownTileType(kind string, rng hcl.Range) *hcl.Diagnostic {
+	detail := fmt.Sprintf("The tile type %q is not supported.", kind)
+	if suggestion := nameSuggestion(kind, TileTypes()); suggestion != "" {
+		detail += fmt.Sprintf(" Did you mean %q?", suggestion)
+	}
+
+	return &hcl.Diagnostic{
+		Severity: hcl.DiagError,
+		Summary:  "Unknown tile type",
+		Detail:   detail,
+		Subject:  rng.Ptr(),
+	}
+}
+
+// isShapeKind checks if the shape is known.
+func isShapeKind(kind string) bool {
+	for _, el := range jumble.ShapeKinds() {
+		if el == kind {
+			return true
+		}
+	}
+
+	return false
+}
+
+// unknownShape reports an unknown shape
+// suggesting the most similar known one.
+func unknownShape(kind string, rng hcl.Range) *hcl.Diagnostic {
+	detail := fmt.Sprintf("The shape %q is not supported.", kind)
+	if suggestion := nameSuggestion(kind, jumble.ShapeKinds()); suggestion != "" {
+		detail += fmt.Sprintf(" Did you mean %q?", suggestion)
+	}
+
+	return &hcl.Diagnostic{
+		Severity: hcl.DiagError,
+		Summary:  "Unknown shape",
+		Detail:   detail,
+		Subject:  rng.Ptr(),
+	}
+}
+
+// nameSuggestion returns the most similar of the given
+// names (if close enough) or an empty string.
+func nameSuggestion(given string, names []string) string {
+	res, best := "", 3 // the max distance (excluded)
+	for _, el := range names {
+		if dist := levenshtein.Distance(given, el, nil); dist < best {
+			res, best = el, dist
+		}
+	}
+
+	return res
+}
+
+// lenientBody is an HCL body that ignores
+// the attributes and blocks not in the schema.
+type lenientBody struct {
+	hcl.Body
+}
+
+// Content is like PartialContent
+// but without the remaining body.
+func (b lenientBody) Content(schema *hcl.BodySchema) (*hcl.BodyContent, hcl.Diagnostics) {
+	content, _, diags := b.Body.PartialContent(schema)
+	if content != nil {
+		for _, blk := range content.Blocks {
+			blk.Body = lenientBody{blk.Body}
+		}
+	}
+
+	return content, diags
+}
@@ -0,0 +1,255 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/hashicorp/hcl2/hcl"
+	"github.com/hashicorp/hcl2/hcl/hclsyntax"
+	"github.com/hashicorp/hcl2/hclparse"
+	"github.com/lucasepe/jumble"
+
+	"github.com/lucasepe/jumble/fetch"
+)
+
+// tileBlock describes the HCL block of a tile.
+type tileBlock struct {
+	kind    string
+	named   bool
+	rng     hcl.Range
+	kindRng hcl.Range
+}
+
+// decodedTile is a tile decoded from an HCL block.
+type decodedTile struct {
+	id    string
+	tile  jumble.Tile
+	block tileBlock
+}
+
+// name returns the tile name used in the diagnostics.
+func (b tileBlock) name(id string) string {
+	if b.named {
+		return fmt.Sprintf("tile '%s'", id)
+	}
+
+	return fmt.Sprintf("unnamed %s tile", b.kind)
+}
+
+// tileSyntaxBlocks returns the syntax of the tile blocks.
+func tileSyntaxBlocks(body hcl.Body) []*hclsyntax.Block {
+	sb, ok := body.(*hclsyntax.Body)
+	if !ok {
+		return nil
+	}
+
+	var res []*hclsyntax.Block
+	for _, blk := range sb.Blocks {
+		if blk.Type == "tile" {
+			res = append(res, blk)
+		}
+	}
+
+	return res
+}
+
+// ValidateURI validates the HCL content of the given uri.
+func ValidateURI(uri string, opts ...func(*DecodeOptions)) (hcl.Diagnostics, map[string]*hcl.File, error) {
+	body, err := fetch.Default.Config(uri)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	diags, files := Validate(body, uri, opts...)
+	return diags, files, nil
+}
+
+// Validate decodes the given buffer (like Decode) and checks the
+// diagram: duplicate tile IDs, tiles out of the grid, reversed
+// frames, unknown assets (errors) and tiles sharing a cell
+// or skipped in lenient mode (warnings). The parsed files
+// are returned as well (e.g. to print the source snippets).
+func Validate(data []byte, uri string, opts ...func(*DecodeOptions)) (hcl.Diagnostics, map[string]*hcl.File) {
+	parser := hclparse.NewParser()
+
+	srcHCL, diags := parseHCL(parser, data, uri)
+	if diags.HasErrors() {
+		return diags, parser.Files()
+	}
+
+	files, err := loadIncludes(parser, srcHCL, uri)
+	if err != nil {
+		return append(diags, errorDiags(err)...), parser.Files()
+	}
+
+	var blocks []*hclsyntax.Block
+	for _, f := range files {
+		blocks = append(blocks, tileSyntaxBlocks(f.Body)...)
+	}
+
+	diags = append(diags, duplicateIDs(blocks)...)
+
+	options := DecodeOptions{duplicates: true}
+	for _, opt := range opts {
+		opt(&options)
+	}
+
+	// the lenient mode skips the unknown tiles
+	if options.Lenient {
+		for _, blk := range blocks {
+			if len(blk.Labels) > 0 && !isTileKind(blk.Labels[0]) {
+				diag := unknownTileType(blk.Labels[0], blk.LabelRanges[0])
+				diag.Severity = hcl.DiagWarning
+				diags = append(diags, diag)
+			}
+		}
+	}
+
+	cfg, err := decode(parser, data, uri, options, nil)
+	if err != nil {
+		return append(diags, errorDiags(err)...), parser.Files()
+	}
+
+	return append(diags, cfg.check()...), parser.Files()
+}
+
+// duplicateIDs reports the tile IDs used more than once.
+func duplicateIDs(blocks []*hclsyntax.Block) hcl.Diagnostics {
+	var diags hcl.Diagnostics
+	seen := map[string]hcl.Range{}
+	for _, blk := range blocks {
+		if len(blk.Labels) < 2 {
+			continue
+		}
+
+		id := blk.Labels[1]
+		if strings.TrimSpace(id) == "" {
+			continue
+		}
+
+		if prev, ok := seen[id]; ok {
+			diags = append(diags, duplicateID(id, prev, blk.LabelRanges[1]))
+			continue
+		}
+		seen[id] = blk.LabelRanges[1]
+	}
+
+	return diags
+}
+
+// duplicateID reports the tile ID (at rng) already used at prev.
+func duplicateID(id string, prev, rng hcl.Range) *hcl.Diagnostic {
+	return &hcl.Diagnostic{
+		Severity: hcl.DiagError,
+		Summary:  "Duplicate tile ID",
+		Detail:   fmt.Sprintf("The tile ID '%s' is already used at %s.", id, prev),
+		Subject:  rng.Ptr(),
+	}
+}
+
+// errorDiags returns the diagnostics wrapped by err.
+func errorDiags(err error) hcl.Diagnostics {
+	var diags hcl.Diagnostics
+	if errors.As(err, &diags) {
+		return diags
+	}
+
+	var diag *hcl.Diagnostic
+	if errors.As(err, &diag) {
+		return hcl.Diagnostics{diag}
+	}
+
+	return hcl.Diagnostics{{
+		Severity: hcl.DiagError,
+		Summary:  err.Error(),
+	}}
+}
+
+// check runs the semantic checks on all the
+// decoded tiles (in declaration order).
+func (cfg *Config) check() hcl.Diagnostics {
+	var diags hcl.Diagnostics
+	cells := map[[2]int]string{}
+	for _, el := range cfg.decoded {
+		id, t, blk := el.id, el.tile, el.block
+		rng := blk.rng
+
+		if fr, ok := t.(*jumble.Frame); ok && (fr.Right < fr.Left || fr.Bottom < fr.Top) {
+			diags = append(diags, &hcl.Diagnostic{
+				Severity: hcl.DiagError,
+				Summary:  "Reversed frame",
+				Detail: fmt.Sprintf("The %s has 'right' (%d) before 'left' (%d) or 'bottom' (%d) before 'top' (%d).",
+					blk.name(id), fr.Right, fr.Left, fr.Bottom, fr.Top),
+				Subject: &rng,
+			})
+			continue
+		}
+
+		row, col := t.Location()
+		rows, cols := 1, 1
+		if sp, ok := t.(jumble.Spanner); ok {
+			rows, cols = sp.Span()
+		}
+
+		if row < 0 || col < 0 || row+rows > cfg.Rows || col+cols > cfg.Cols {
+			diags = append(diags, &hcl.Diagnostic{
+				Severity: hcl.DiagError,
+				Summary:  "Tile out of bounds",
+				Detail: fmt.Sprintf("The %s covers the cells from (%d, %d) to (%d, %d), outside the %d x %d grid.",
+					blk.name(id), row, col, row+rows-1, col+cols-1, cfg.Rows, cfg.Cols),
+				Subject: &rng,
+			})
+			continue
+		}
+
+		if src, ok := t.(jumble.ImageSource); ok {
+			for _, uri := range src.ImageURIs() {
+				if !strings.HasPrefix(uri, "assets://") {
+					continue
+				}
+
+				file, err := jumble.OpenAsset(uri)
+				if err != nil {
+					diags = append(diags, &hcl.Diagnostic{
+						Severity: hcl.DiagError,
+						Summary:  "Unknown asset",
+						Detail:   fmt.Sprintf("The %s uses '%s' which is not in any asset pack.", blk.name(id), uri),
+						Subject:  &rng,
+					})
+					continue
+				}
+				file.Close()
+			}
+		}
+
+		// frames enclose the other tiles
+		if _, ok := t.(*jumble.Frame); ok {
+			continue
+		}
+
+		overlap := false
+		for r := row; r < row+rows; r++ {
+			for c := col; c < col+cols; c++ {
+				other, ok := cells[[2]int{r, c}]
+				if !ok {
+					cells[[2]int{r, c}] = blk.name(id)
+					continue
+				}
+
+				if !overlap {
+					diags = append(diags, &hcl.Diagnostic{
+						Severity: hcl.DiagWarning,
+						Summary:  "Overlapping tiles",
+						Detail: fmt.Sprintf("The %s overlaps the %s in the cell (%d, %d).",
+							blk.name(id), other, r, c),
+						Subject: &rng,
+					})
+				}
+				overlap = true
+			}
+		}
+	}
+
+	return diags
+}
@@ -255,6 +255,12 @@ func (c *Connector) Location() (int, int) {
 	return c.Row, c.Col
 }
 
+// Move moves the connector by some rows and columns
+func (c *Connector) Move(rows, cols int) {
+	c.Row += rows
+	c.Col += cols
+}
+
 // Plot draws a connector on the grid.
 func (c *Connector) Plot(g *Grid) error {
 	if err := g.VerifyInBounds(c.Row, c.Col); err != nil {
@@ -0,0 +1,79 @@
+package fetch
+
+import (
+	"fmt"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// EnvOptions returns the fetcher options defined in the environment:
+//
+//	JUMBLE_HTTP_TIMEOUT   time limit of each request (e.g. 10s)
+//	JUMBLE_HTTP_RETRIES   retries on network and server errors
+//	JUMBLE_HTTP_HEADERS   extra headers ("Name: value; Other: value")
+//	JUMBLE_AUTH_TOKEN     bearer token sent as Authorization header
+//	JUMBLE_AUTH_HOSTS     hosts receiving the headers and the token
+//	                      ("example.com, cdn.example.com:8080")
+//
+// The headers and the token are credentials: they are sent only to
+// the JUMBLE_AUTH_HOSTS or, when it is not set, to the host of the
+// configuration uri (never to the other hosts named by a diagram).
+func EnvOptions(configURI string) ([]func(*Fetcher), error) {
+	var res []func(*Fetcher)
+
+	if val := os.Getenv("JUMBLE_HTTP_TIMEOUT"); val != "" {
+		d, err := time.ParseDuration(val)
+		if err != nil {
+			return nil, fmt.Errorf("invalid JUMBLE_HTTP_TIMEOUT: %w", err)
+		}
+		res = append(res, Timeout(d))
+	}
+
+	if val := os.Getenv("JUMBLE_HTTP_RETRIES"); val != "" {
+		n, err := strconv.Atoi(val)
+		if err != nil {
+			return nil, fmt.Errorf("invalid JUMBLE_HTTP_RETRIES: %w", err)
+		}
+		if n < 0 {
+			return nil, fmt.Errorf("invalid JUMBLE_HTTP_RETRIES: %d is negative", n)
+		}
+		res = append(res, Retries(n))
+	}
+
+	if val := os.Getenv("JUMBLE_HTTP_HEADERS"); val != "" {
+		for _, el := range strings.Split(val, ";") {
+			if strings.TrimSpace(el) == "" {
+				continue
+			}
+
+			parts := strings.SplitN(el, ":", 2)
+			if len(parts) != 2 {
+				return nil, fmt.Errorf("invalid JUMBLE_HTTP_HEADERS: %q is not 'Name: value'", el)
+			}
+			res = append(res, AuthHeader(strings.TrimSpace(parts[0]), strings.TrimSpace(parts[1])))
+		}
+	}
+
+	if val := os.Getenv("JUMBLE_AUTH_TOKEN"); val != "" {
+		res = append(res, AuthHeader("Authorization", "Bearer "+val))
+	}
+
+	var hosts []string
+	for _, el := range strings.Split(os.Getenv("JUMBLE_AUTH_HOSTS"), ",") {
+		if el = strings.TrimSpace(el); el != "" {
+			hosts = append(hosts, el)
+		}
+	}
+
+	if len(hosts) == 0 && strings.HasPrefix(configURI, "http") {
+		if u, err := url.Parse(configURI); err == nil && u.Host != "" {
+			hosts = append(hosts, u.Host)
+		}
+	}
+	res = append(res, AuthHosts(hosts...))
+
+	return res, nil
+}
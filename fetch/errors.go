@@ -0,0 +1,70 @@
+package fetch
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ErrTooLarge is returned when a resource exceeds the size limit.
+var ErrTooLarge = errors.New("resource too large")
+
+// Error records a failed fetch and the URI that caused it.
+type Error struct {
+	URI string
+	Err error
+}
+
+// Error implement the standard library
+// interface type for errors.
+func (e *Error) Error() string {
+	return fmt.Sprintf("fetching '%s': %s", e.URI, e.Err.Error())
+}
+
+// Unwrap returns the underlying error.
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// LimitError is returned when a resource exceeds the size limit.
+type LimitError struct {
+	Limit int64
+}
+
+// Error implement the standard library
+// interface type for errors.
+func (e *LimitError) Error() string {
+	return fmt.Sprintf("%s (limit %d bytes)", ErrTooLarge.Error(), e.Limit)
+}
+
+// Is makes errors.Is(err, ErrTooLarge) work.
+func (e *LimitError) Is(target error) bool {
+	return target == ErrTooLarge
+}
+
+// StatusError is returned when the server
+// replies with an unexpected status code.
+type StatusError struct {
+	Code   int
+	Status string
+}
+
+// Error implement the standard library
+// interface type for errors.
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("unexpected status: %s", e.Status)
+}
+
+// ContentTypeError is returned when the server
+// replies with an unexpected content type.
+type ContentTypeError struct {
+	Got  string
+	Want []string
+}
+
+// Error implement the standard library
+// interface type for errors.
+func (e *ContentTypeError) Error() string {
+	return fmt.Sprintf("unexpected content type: %s (want: %s)",
+		e.Got, strings.Join(e.Want, ", "))
+}
@@ -0,0 +1,356 @@
+// Package fetch loads images, fonts and configurations from HTTP URLs
+// and local files, enforcing timeouts, size limits and content
+// types. Remote resources can be stored in an on-disk cache.
+package fetch
+
+import (
+	"errors"
+	"io"
+	"io/ioutil"
+	"mime"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/lucasepe/jumble/cache"
+)
+
+var (
+	imageTypes  = []string{"image/", "application/octet-stream", "binary/octet-stream"}
+	configTypes = []string{"text/", "application/"}
+	fontTypes   = []string{"font/", "application/font", "application/x-font",
+		"application/vnd.ms-opentype", "application/octet-stream", "binary/octet-stream"}
+)
+
+// Default is the fetcher shared by images, fonts and configurations.
+var Default = New()
+
+// Fetcher loads the resources.
+type Fetcher struct {
+	client      *http.Client
+	retries     int
+	headers     http.Header
+	authHeaders http.Header
+	authHosts   []string
+	cache       *cache.Cache
+	imageLimit  int64
+	configLimit int64
+	fontLimit   int64
+}
+
+// New returns a new fetcher and sets it up with its configuration.
+func New(opts ...func(*Fetcher)) *Fetcher {
+	res := Fetcher{
+		client:      &http.Client{Timeout: 30 * time.Second},
+		retries:     2,
+		headers:     http.Header{},
+		authHeaders: http.Header{},
+		imageLimit:  200 * 1024,
+		configLimit: 100 * 1024,
+		fontLimit:   2 * 1024 * 1024,
+	}
+
+	for _, opt := range opts {
+		opt(&res)
+	}
+	res.client.CheckRedirect = res.checkRedirect
+
+	return &res
+}
+
+// Timeout sets the time limit of each HTTP request.
+func Timeout(val time.Duration) func(*Fetcher) {
+	return func(f *Fetcher) {
+		f.client.Timeout = val
+	}
+}
+
+// Retries sets how many times a request is retried on
+// network and server errors (negative values mean none).
+func Retries(val int) func(*Fetcher) {
+	return func(f *Fetcher) {
+		if val < 0 {
+			val = 0
+		}
+		f.retries = val
+	}
+}
+
+// Header adds a header to each HTTP request.
+func Header(name, value string) func(*Fetcher) {
+	return func(f *Fetcher) {
+		f.headers.Add(name, value)
+	}
+}
+
+// AuthHeader adds a header (e.g. a credential)
+// sent only to the auth hosts.
+func AuthHeader(name, value string) func(*Fetcher) {
+	return func(f *Fetcher) {
+		f.authHeaders.Add(name, value)
+	}
+}
+
+// AuthHosts adds the hosts (e.g. 'example.com' or
+// 'example.com:8080') that receive the auth headers.
+func AuthHosts(hosts ...string) func(*Fetcher) {
+	return func(f *Fetcher) {
+		f.authHosts = append(f.authHosts, hosts...)
+	}
+}
+
+// Cache sets the on-disk cache for remote resources.
+func Cache(val *cache.Cache) func(*Fetcher) {
+	return func(f *Fetcher) {
+		f.cache = val
+	}
+}
+
+// ImageLimit sets the max size in bytes of an image.
+func ImageLimit(val int64) func(*Fetcher) {
+	return func(f *Fetcher) {
+		f.imageLimit = val
+	}
+}
+
+// ConfigLimit sets the max size in bytes of a configuration.
+func ConfigLimit(val int64) func(*Fetcher) {
+	return func(f *Fetcher) {
+		f.configLimit = val
+	}
+}
+
+// FontLimit sets the max size in bytes of a font.
+func FontLimit(val int64) func(*Fetcher) {
+	return func(f *Fetcher) {
+		f.fontLimit = val
+	}
+}
+
+// Image loads an image from an HTTP URL or a local file.
+func (f *Fetcher) Image(uri string) ([]byte, error) {
+	return f.Fetch(uri, f.imageLimit, imageTypes...)
+}
+
+// Config loads a configuration from an HTTP URL or a local file.
+func (f *Fetcher) Config(uri string) ([]byte, error) {
+	return f.Fetch(uri, f.configLimit, configTypes...)
+}
+
+// Font loads a TTF/OTF font from an HTTP URL or a local file.
+func (f *Fetcher) Font(uri string) ([]byte, error) {
+	return f.Fetch(uri, f.fontLimit, fontTypes...)
+}
+
+// Fetch loads (with limit) the resource at the specified URI.
+// If the URI starts with http, the resource is fetched with
+// a GET verb and the response content type must match one
+// of the accepted media type prefixes (if any).
+// All the errors are returned as *Error.
+func (f *Fetcher) Fetch(uri string, limit int64, accept ...string) ([]byte, error) {
+	var data []byte
+	var err error
+
+	if strings.HasPrefix(uri, "http") {
+		data, err = f.fetchURL(uri, limit, accept)
+	} else {
+		data, err = readFile(uri, limit)
+	}
+
+	if err != nil {
+		return nil, &Error{URI: uri, Err: err}
+	}
+
+	return data, nil
+}
+
+// fetchURL loads a remote resource through the cache.
+// If the network fails an expired entry is still served.
+func (f *Fetcher) fetchURL(uri string, limit int64, accept []string) ([]byte, error) {
+	var ent *cache.Entry
+	var cached []byte
+
+	if f.cache != nil {
+		el, data, found := f.cache.Lookup(uri)
+		if found && f.cache.Fresh(el) {
+			return data, nil
+		}
+
+		if f.cache.IsOffline() {
+			return nil, cache.ErrNotCached
+		}
+
+		if found {
+			ent, cached = &el, data
+		}
+	}
+
+	res, err := f.get(uri, ent)
+	if err != nil {
+		if ent != nil {
+			return cached, nil
+		}
+		return nil, err
+	}
+	defer res.Body.Close()
+
+	if ent != nil && res.StatusCode == http.StatusNotModified {
+		return cached, f.cache.Touch(*ent)
+	}
+
+	if res.StatusCode != http.StatusOK {
+		return nil, &StatusError{Code: res.StatusCode, Status: res.Status}
+	}
+
+	if err := checkContentType(res.Header.Get("Content-Type"), accept); err != nil {
+		return nil, err
+	}
+
+	data, err := readAll(res.Body, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	if f.cache != nil {
+		err = f.cache.Store(uri, data,
+			res.Header.Get("ETag"), res.Header.Get("Last-Modified"))
+	}
+
+	return data, err
+}
+
+// get sends a (conditional) GET request
+// retrying on network and server errors.
+func (f *Fetcher) get(uri string, ent *cache.Entry) (*http.Response, error) {
+	lastErr := errors.New("no request attempted")
+
+	for attempt := 0; attempt <= f.retries; attempt++ {
+		if attempt > 0 {
+			time.Sleep(time.Duration(attempt) * 200 * time.Millisecond)
+		}
+
+		req, err := http.NewRequest(http.MethodGet, uri, nil)
+		if err != nil {
+			return nil, err
+		}
+
+		for name, values := range f.headers {
+			for _, val := range values {
+				req.Header.Add(name, val)
+			}
+		}
+
+		if f.authorized(req.URL) {
+			for name, values := range f.authHeaders {
+				for _, val := range values {
+					req.Header.Add(name, val)
+				}
+			}
+		}
+
+		if ent != nil {
+			if ent.ETag != "" {
+				req.Header.Set("If-None-Match", ent.ETag)
+			}
+			if ent.LastModified != "" {
+				req.Header.Set("If-Modified-Since", ent.LastModified)
+			}
+		}
+
+		res, err := f.client.Do(req)
+		if err != nil {
+			lastErr = err
+			continue
+		}
+
+		if res.StatusCode >= http.StatusInternalServerError {
+			res.Body.Close()
+			lastErr = &StatusError{Code: res.StatusCode, Status: res.Status}
+			continue
+		}
+
+		return res, nil
+	}
+
+	return nil, lastErr
+}
+
+// authorized tells whether the auth headers can be sent to the url.
+func (f *Fetcher) authorized(u *url.URL) bool {
+	for _, el := range f.authHosts {
+		if strings.EqualFold(el, u.Host) || strings.EqualFold(el, u.Hostname()) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// checkRedirect removes the auth headers
+// when redirected to another host.
+func (f *Fetcher) checkRedirect(req *http.Request, via []*http.Request) error {
+	if len(via) >= 10 {
+		return errors.New("stopped after 10 redirects")
+	}
+
+	if !f.authorized(req.URL) {
+		for name := range f.authHeaders {
+			req.Header.Del(name)
+		}
+	}
+
+	return nil
+}
+
+// checkContentType verifies that the content
+// type matches one of the accepted prefixes.
+func checkContentType(ct string, accept []string) error {
+	if ct == "" || len(accept) == 0 {
+		return nil
+	}
+
+	mt, _, err := mime.ParseMediaType(ct)
+	if err != nil {
+		return &ContentTypeError{Got: ct, Want: accept}
+	}
+
+	for _, el := range accept {
+		if strings.HasPrefix(mt, el) {
+			return nil
+		}
+	}
+
+	return &ContentTypeError{Got: mt, Want: accept}
+}
+
+// readFile reads (with limit) a local file.
+func readFile(filename string, limit int64) ([]byte, error) {
+	file, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	return readAll(file, limit)
+}
+
+// readAll reads everything failing if the
+// data exceeds the limit (when positive).
+func readAll(r io.Reader, limit int64) ([]byte, error) {
+	if limit <= 0 {
+		return ioutil.ReadAll(r)
+	}
+
+	data, err := ioutil.ReadAll(io.LimitReader(r, limit+1))
+	if err != nil {
+		return nil, err
+	}
+
+	if int64(len(data)) > limit {
+		return nil, &LimitError{Limit: limit}
+	}
+
+	return data, nil
+}
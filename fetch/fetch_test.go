@@ -0,0 +1,232 @@
+package fetch
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/lucasepe/jumble/cache"
+)
+
+func TestFetchRevalidate(t *testing.T) {
+	hits, fulls := 0, 0
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits++
+		if r.Header.Get("If-None-Match") == `"v1"` {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		fulls++
+		w.Header().Set("ETag", `"v1"`)
+		fmt.Fprint(w, "Hello from jumble!")
+	}))
+	defer ts.Close()
+
+	dir, err := ioutil.TempDir("", "jumble-cache")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	tests := []struct {
+		ttl   time.Duration
+		hits  int
+		fulls int
+	}{
+		{time.Hour, 1, 1},
+		{time.Hour, 1, 1},
+		{0, 2, 1},
+	}
+
+	for _, tt := range tests {
+		f := New(Cache(cache.New(dir, cache.TTL(tt.ttl))))
+		data, err := f.Config(ts.URL)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if got, want := string(data), "Hello from jumble!"; got != want {
+			t.Errorf("got [%v] want [%v]", got, want)
+		}
+
+		if hits != tt.hits || fulls != tt.fulls {
+			t.Errorf("got [%d, %d] requests want [%d, %d]", hits, fulls, tt.hits, tt.fulls)
+		}
+	}
+}
+
+func TestFetchOffline(t *testing.T) {
+	dir, err := ioutil.TempDir("", "jumble-cache")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, "cached")
+	}))
+
+	if _, err := New(Cache(cache.New(dir))).Config(ts.URL); err != nil {
+		t.Fatal(err)
+	}
+	ts.Close()
+
+	f := New(Cache(cache.New(dir, cache.TTL(0), cache.Offline(true))))
+	data, err := f.Config(ts.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := string(data); got != "cached" {
+		t.Errorf("got [%v] want [cached]", got)
+	}
+
+	if _, err := f.Config(ts.URL + "/missing"); !errors.Is(err, cache.ErrNotCached) {
+		t.Errorf("got [%v] want [%v]", err, cache.ErrNotCached)
+	}
+}
+
+func TestFetchErrors(t *testing.T) {
+	fails := 0
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/big":
+			fmt.Fprint(w, strings.Repeat("x", 64))
+		case "/font":
+			w.Header().Set("Content-Type", "font/ttf")
+			fmt.Fprint(w, strings.Repeat("x", 64))
+		case "/html":
+			w.Header().Set("Content-Type", "text/html")
+			fmt.Fprint(w, "<html></html>")
+		case "/flaky":
+			if fails < 2 {
+				fails++
+				w.WriteHeader(http.StatusServiceUnavailable)
+				return
+			}
+			fmt.Fprint(w, "ok")
+		case "/auth":
+			if r.Header.Get("Authorization") != "Bearer s3cr3t" {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			fmt.Fprint(w, "ok")
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer ts.Close()
+
+	f := New(ConfigLimit(32), FontLimit(32), Retries(2), Header("Authorization", "Bearer s3cr3t"))
+
+	_, err := f.Config(ts.URL + "/big")
+	if !errors.Is(err, ErrTooLarge) {
+		t.Errorf("got [%v] want [%v]", err, ErrTooLarge)
+	}
+
+	var fe *Error
+	if !errors.As(err, &fe) || fe.URI != ts.URL+"/big" {
+		t.Errorf("got [%v] want a fetch error for [%s]", err, ts.URL+"/big")
+	}
+
+	if _, err := f.Font(ts.URL + "/font"); !errors.Is(err, ErrTooLarge) {
+		t.Errorf("got [%v] want [%v]", err, ErrTooLarge)
+	}
+
+	var cte *ContentTypeError
+	if _, err := f.Image(ts.URL + "/html"); !errors.As(err, &cte) {
+		t.Errorf("got [%v] want a content type error", err)
+	}
+
+	if _, err := f.Font(ts.URL + "/html"); !errors.As(err, &cte) {
+		t.Errorf("got [%v] want a content type error", err)
+	}
+
+	var se *StatusError
+	if _, err := f.Config(ts.URL + "/missing"); !errors.As(err, &se) || se.Code != http.StatusNotFound {
+		t.Errorf("got [%v] want a 404 status error", err)
+	}
+
+	if _, err := f.Config(ts.URL + "/flaky"); err != nil {
+		t.Errorf("got [%v] want no error after retries", err)
+	}
+
+	if _, err := f.Config(ts.URL + "/auth"); err != nil {
+		t.Errorf("got [%v] want no error with the auth header", err)
+	}
+	// a negative retry count still sends the request once
+	if _, err := New(Retries(-1)).Config(ts.URL + "/missing"); !errors.As(err, &se) {
+		t.Errorf("got [%v] want a status error", err)
+	}
+}
+
+func TestEnvOptions(t *testing.T) {
+	os.Setenv("JUMBLE_HTTP_RETRIES", "-1")
+	defer os.Unsetenv("JUMBLE_HTTP_RETRIES")
+
+	if _, err := EnvOptions(""); err == nil {
+		t.Error("succeeded; want error")
+	}
+}
+
+func TestAuthHeaders(t *testing.T) {
+	other := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "" {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		fmt.Fprint(w, "ok")
+	}))
+	defer other.Close()
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/redirect" {
+			http.Redirect(w, r, other.URL, http.StatusFound)
+			return
+		}
+		if r.Header.Get("Authorization") != "Bearer s3cr3t" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		fmt.Fprint(w, "ok")
+	}))
+	defer ts.Close()
+
+	os.Setenv("JUMBLE_AUTH_TOKEN", "s3cr3t")
+	defer os.Unsetenv("JUMBLE_AUTH_TOKEN")
+
+	// the token goes only to the host of the configuration
+	env, err := EnvOptions(ts.URL + "/diagram.hcl")
+	if err != nil {
+		t.Fatal(err)
+	}
+	f := New(env...)
+
+	if _, err := f.Config(ts.URL); err != nil {
+		t.Errorf("got [%v] want no error with the auth header", err)
+	}
+
+	if _, err := f.Config(other.URL); err != nil {
+		t.Errorf("got [%v] want no auth header for another host", err)
+	}
+
+	if _, err := f.Config(ts.URL + "/redirect"); err != nil {
+		t.Errorf("got [%v] want no auth header after a redirect", err)
+	}
+
+	// the token goes nowhere without the auth hosts
+	env, err = EnvOptions("diagram.hcl")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var se *StatusError
+	if _, err := New(env...).Config(ts.URL); !errors.As(err, &se) || se.Code != http.StatusUnauthorized {
+		t.Errorf("got [%v] want a 401 status error", err)
+	}
+}
@@ -0,0 +1,234 @@
+package jumble
+
+import (
+	"container/list"
+	"fmt"
+	"io/ioutil"
+	"strings"
+	"sync"
+
+	"github.com/golang/freetype/truetype"
+	"github.com/lucasepe/jumble/fetch"
+	"golang.org/x/image/font"
+	"golang.org/x/image/font/gofont/gobold"
+	"golang.org/x/image/font/gofont/gobolditalic"
+	"golang.org/x/image/font/gofont/goitalic"
+	"golang.org/x/image/font/gofont/gomono"
+	"golang.org/x/image/font/gofont/gomonobold"
+	"golang.org/x/image/font/gofont/gomonobolditalic"
+	"golang.org/x/image/font/gofont/gomonoitalic"
+	"golang.org/x/image/font/gofont/goregular"
+	"golang.org/x/image/font/opentype"
+	"golang.org/x/image/font/sfnt"
+)
+
+// DefaultFont is the name of the default font family (Go Regular).
+const DefaultFont = "go"
+
+// Font is a parsed TrueType or OpenType font.
+type Font struct {
+	ttf *truetype.Font
+	otf *sfnt.Font
+}
+
+// FontFamily groups the style variants of a font;
+// missing variants fall back to the regular one.
+type FontFamily struct {
+	Regular    *Font
+	Bold       *Font
+	Italic     *Font
+	BoldItalic *Font
+}
+
+// maxFontFaces is the maximum number of cached font faces
+// (the auto-sizing of the text tries many sizes).
+const maxFontFaces = 64
+
+// faceKey identifies a cached font face.
+type faceKey struct {
+	font *Font
+	size float64
+}
+
+// cachedFace is an entry of the font faces LRU cache.
+type cachedFace struct {
+	key  faceKey
+	face font.Face
+}
+
+var (
+	fontsMu      sync.Mutex
+	fontFamilies = map[string]*FontFamily{}
+	fontFaces    = map[faceKey]*list.Element{}
+	fontFacesLRU = list.New()
+
+	// builtinFonts are the bundled Go font families.
+	builtinFonts = map[string][4][]byte{
+		DefaultFont: {goregular.TTF, gobold.TTF, goitalic.TTF, gobolditalic.TTF},
+		"mono":      {gomono.TTF, gomonobold.TTF, gomonoitalic.TTF, gomonobolditalic.TTF},
+	}
+)
+
+// ParseFont parses TrueType (TTF) or OpenType (OTF) font data.
+func ParseFont(data []byte) (*Font, error) {
+	if f, err := truetype.Parse(data); err == nil {
+		return &Font{ttf: f}, nil
+	}
+
+	f, err := sfnt.Parse(data)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Font{otf: f}, nil
+}
+
+// LoadFont loads a font from a file, an HTTP URL or the asset packs.
+func LoadFont(uri string) (*Font, error) {
+	var data []byte
+	var err error
+
+	if strings.HasPrefix(uri, "assets://") {
+		data, err = readAsset(uri)
+	} else {
+		data, err = fetch.Default.Font(uri)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	res, err := ParseFont(data)
+	if err != nil {
+		return nil, &fetch.Error{URI: uri, Err: err}
+	}
+
+	return res, nil
+}
+
+// RegisterFontFamily registers a font family with the specified name.
+func RegisterFontFamily(name string, fam FontFamily) error {
+	if strings.TrimSpace(name) == "" {
+		return fmt.Errorf("font family name can't be empty")
+	}
+
+	if fam.Regular == nil {
+		return fmt.Errorf("font family '%s' has no regular font", name)
+	}
+
+	fontsMu.Lock()
+	fontFamilies[name] = &fam
+	fontsMu.Unlock()
+
+	return nil
+}
+
+// Face returns a font face of the specified size.
+// The most recently used faces are cached, so repeated calls are cheap.
+func (f *Font) Face(size float64) font.Face {
+	key := faceKey{font: f, size: size}
+
+	fontsMu.Lock()
+	defer fontsMu.Unlock()
+
+	if el, ok := fontFaces[key]; ok {
+		fontFacesLRU.MoveToFront(el)
+		return el.Value.(*cachedFace).face
+	}
+
+	var face font.Face
+	if f.ttf != nil {
+		face = truetype.NewFace(f.ttf, &truetype.Options{Size: size})
+	} else {
+		// opentype.NewFace never fails
+		face, _ = opentype.NewFace(f.otf, &opentype.FaceOptions{
+			Size: size, DPI: 72, Hinting: font.HintingNone,
+		})
+	}
+	fontFaces[key] = fontFacesLRU.PushFront(&cachedFace{key: key, face: face})
+
+	if fontFacesLRU.Len() > maxFontFaces {
+		el := fontFacesLRU.Back()
+		fontFacesLRU.Remove(el)
+		delete(fontFaces, el.Value.(*cachedFace).key)
+	}
+
+	return face
+}
+
+// HasGlyph reports whether the font has a glyph for the rune.
+func (f *Font) HasGlyph(r rune) bool {
+	if f.ttf != nil {
+		return f.ttf.Index(r) != 0
+	}
+
+	var buf sfnt.Buffer
+	idx, err := f.otf.GlyphIndex(&buf, r)
+
+	return err == nil && idx != 0
+}
+
+// Variant returns the font of the specified style.
+func (fam *FontFamily) Variant(bold, italic bool) *Font {
+	var res *Font
+	switch {
+	case bold && italic:
+		res = fam.BoldItalic
+	case bold:
+		res = fam.Bold
+	case italic:
+		res = fam.Italic
+	}
+
+	if res == nil {
+		res = fam.Regular
+	}
+
+	return res
+}
+
+// fontFamily returns the named (registered or bundled) font family.
+func fontFamily(name string) (*FontFamily, error) {
+	if name == "" {
+		name = DefaultFont
+	}
+
+	fontsMu.Lock()
+	defer fontsMu.Unlock()
+
+	if fam, ok := fontFamilies[name]; ok {
+		return fam, nil
+	}
+
+	src, ok := builtinFonts[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown font: %s", name)
+	}
+
+	fonts := make([]*Font, len(src))
+	for i, data := range src {
+		f, err := truetype.Parse(data)
+		if err != nil {
+			return nil, err
+		}
+		fonts[i] = &Font{ttf: f}
+	}
+
+	fam := &FontFamily{
+		Regular: fonts[0], Bold: fonts[1],
+		Italic: fonts[2], BoldItalic: fonts[3],
+	}
+	fontFamilies[name] = fam
+
+	return fam, nil
+}
+
+// readAsset reads the content of an `assets://` URI.
+func readAsset(uri string) ([]byte, error) {
+	file, err := OpenAsset(uri)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	return ioutil.ReadAll(file)
+}
@@ -0,0 +1,102 @@
+package jumble
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/image/font/gofont/gosmallcaps"
+)
+
+func TestFontFamily(t *testing.T) {
+	fam, err := fontFamily("")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.NotEqual(t, fam.Regular, fam.Variant(true, false))
+	assert.Equal(t, fam.Regular.Face(12), fam.Regular.Face(12))
+
+	_, err = fontFamily("comic")
+	assert.Error(t, err)
+
+	_, err = NewGrid(2, 2, 32, GridFont("comic"))
+	assert.Error(t, err)
+}
+
+func TestFontFaceCache(t *testing.T) {
+	fam, err := fontFamily("")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	face := fam.Regular.Face(10)
+	for i := 0; i < 2*maxFontFaces; i++ {
+		fam.Regular.Face(10 + 0.1*float64(i+1))
+		assert.Equal(t, face, fam.Regular.Face(10))
+	}
+
+	fontsMu.Lock()
+	assert.Len(t, fontFaces, maxFontFaces)
+	assert.Equal(t, maxFontFaces, fontFacesLRU.Len())
+	fontsMu.Unlock()
+}
+
+func TestRegisterFontFamily(t *testing.T) {
+	f, err := ParseFont(gosmallcaps.TTF)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := RegisterFontFamily("caps", FontFamily{Regular: f}); err != nil {
+		t.Fatal(err)
+	}
+
+	fam, err := fontFamily("caps")
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, f, fam.Variant(true, true))
+
+	assert.Error(t, RegisterFontFamily("empty", FontFamily{}))
+
+	_, err = ParseFont([]byte("not a font"))
+	assert.Error(t, err)
+}
+
+func TestFontFallback(t *testing.T) {
+	fam, err := fontFamily("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.True(t, fam.Regular.HasGlyph('→'))
+	assert.False(t, fam.Regular.HasGlyph('日'))
+
+	base := textStyle{family: "go", mono: true, size: 12}
+	got := splitRunes(textRun{text: "ab日本c", style: base}, func(r rune) string {
+		if r > 0xff {
+			return "cjk"
+		}
+		return ""
+	})
+
+	cjk := base
+	cjk.family, cjk.mono = "cjk", false
+	assert.Equal(t, []textRun{
+		{text: "ab", style: base}, {text: "日本", style: cjk}, {text: "c", style: base},
+	}, got)
+
+	_, err = NewGrid(2, 2, 32, GridFallbackFonts("comic"))
+	assert.Error(t, err)
+
+	// no glyph in the chain: the run font is kept
+	grid, err := NewGrid(2, 2, 32, GridFallbackFonts("mono"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	runs, err := grid.fallbackRuns([]textRun{{text: "x 日", style: textStyle{}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, []textRun{{text: "x 日", style: textStyle{}}}, runs)
+}
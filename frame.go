@@ -1,5 +1,35 @@
 package jumble
 
+import (
+	"fmt"
+	"image"
+	"math"
+
+	"github.com/disintegration/imaging"
+	"github.com/fogleman/gg"
+)
+
+// Frame alignments.
+const (
+	// FrameAlignCenter puts the frame edges on the cell centers.
+	FrameAlignCenter = "center"
+	// FrameAlignOuter puts the frame edges on the outer borders
+	// of the corner cells, enclosing them (best for grouping).
+	FrameAlignOuter = "outer"
+	// FrameAlignInner puts the frame edges on the inner borders
+	// of the corner cells, leaving them out.
+	FrameAlignInner = "inner"
+)
+
+// Frame title styles.
+const (
+	// TitleBand draws the title in a band across the frame top.
+	TitleBand = "band"
+	// TitleTab draws the title in a tab at the frame top corner
+	// (top center for oval frames).
+	TitleTab = "tab"
+)
+
 // FrameOval sets the frame shape to
 // oval (default is rectangle)
 func FrameOval(val bool) func(f *Frame) {
@@ -36,6 +66,105 @@ func FrameStrokeWidth(val float64) func(f *Frame) {
 	}
 }
 
+// FrameTitle sets the frame title
+func FrameTitle(text string) func(f *Frame) {
+	return func(fr *Frame) {
+		fr.title = text
+	}
+}
+
+// FrameTitleColor sets the frame title color
+func FrameTitleColor(hex string) func(f *Frame) {
+	return func(fr *Frame) {
+		fr.titleColor = hex
+	}
+}
+
+// FrameTitleBackground sets the title band (or tab)
+// color; by default it's the frame color
+func FrameTitleBackground(hex string) func(f *Frame) {
+	return func(fr *Frame) {
+		fr.titleBackground = hex
+	}
+}
+
+// FrameTitleIcon sets the image drawn before the title
+func FrameTitleIcon(uri string) func(f *Frame) {
+	return func(fr *Frame) {
+		fr.titleIcon = uri
+	}
+}
+
+// FrameTitleStyle sets how the title is drawn
+// (TitleBand or TitleTab)
+func FrameTitleStyle(val string) func(f *Frame) {
+	return func(fr *Frame) {
+		fr.titleStyle = val
+	}
+}
+
+// FrameFill sets the fill color; unlike the
+// color it can be combined with the stroke
+func FrameFill(hex string) func(f *Frame) {
+	return func(fr *Frame) {
+		fr.fill = hex
+	}
+}
+
+// FrameStrokeColor sets the stroke color
+// (and enables the stroke)
+func FrameStrokeColor(hex string) func(f *Frame) {
+	return func(fr *Frame) {
+		fr.strokeColor = hex
+	}
+}
+
+// FrameCornerRadius sets the corner
+// radius of rectangular frames
+func FrameCornerRadius(val float64) func(f *Frame) {
+	return func(fr *Frame) {
+		fr.cornerRadius = val
+	}
+}
+
+// FrameGradient sets a gradient fill
+func FrameGradient(val *Gradient) func(f *Frame) {
+	return func(fr *Frame) {
+		fr.gradient = val
+	}
+}
+
+// FrameAlign sets how the frame edges snap to the
+// cells (FrameAlignCenter, FrameAlignOuter or FrameAlignInner)
+func FrameAlign(val string) func(f *Frame) {
+	return func(fr *Frame) {
+		fr.align = val
+	}
+}
+
+// FrameInset moves the frame edges inwards
+// (outwards if negative) by the pixels
+func FrameInset(val float64) func(f *Frame) {
+	return func(fr *Frame) {
+		fr.inset = val
+	}
+}
+
+// FrameInsetCells moves the frame edges inwards (outwards
+// if negative) by the fraction of the cell size
+func FrameInsetCells(val float64) func(f *Frame) {
+	return func(fr *Frame) {
+		fr.insetCells = val
+	}
+}
+
+// FrameShadow sets the frame drop shadow
+func FrameShadow(val *Shadow) func(f *Frame) {
+	return func(fr *Frame) {
+		fr.shadow = val
+	}
+}
+
 // Frame represents a frame on the grid.
 type Frame struct {
 	Left        int
@@ -47,6 +176,23 @@ type Frame struct {
 	stroke      bool
 	strokeWidth float64
 	oval        bool
+
+	title           string
+	titleColor      string
+	titleBackground string
+	titleIcon       string
+	titleStyle      string
+
+	fill         string
+	strokeColor  string
+	cornerRadius float64
+	gradient     *Gradient
+
+	align      string
+	inset      float64
+	insetCells float64
+
+	shadow *Shadow
 }
 
 // NewFrame returns a new frame
@@ -59,6 +205,8 @@ func NewFrame(l, t int, r, b int, opts ...func(*Frame)) Frame {
 		stroke:      true,
 		strokeWidth: 1,
 		dashes:      5,
+		titleColor:  "#ffffff",
+		titleStyle:  TitleBand,
 	}
 
 	for _, opt := range opts {
@@ -69,8 +217,31 @@ func NewFrame(l, t int, r, b int, opts ...func(*Frame)) Frame {
 }
 
 // Location returns the grid position (row, col)
+// of the top left cell
 func (fr *Frame) Location() (int, int) {
-	return fr.Left, fr.Right
+	return fr.Left, fr.Top
+}
+
+// Move moves the frame by some rows and columns
+func (fr *Frame) Move(rows, cols int) {
+	fr.Left += rows
+	fr.Right += rows
+	fr.Top += cols
+	fr.Bottom += cols
+}
+
+// Span returns the number of rows and columns covered by the frame
+func (fr *Frame) Span() (int, int) {
+	return fr.Right - fr.Left + 1, fr.Bottom - fr.Top + 1
+}
+
+// ImageURIs returns the URI of the title icon (if any)
+func (fr *Frame) ImageURIs() []string {
+	if fr.titleIcon == "" {
+		return nil
+	}
+
+	return []string{fr.titleIcon}
 }
 
 // Plot draws a frame accross the specified cells
@@ -79,36 +250,244 @@ func (fr *Frame) Plot(g *Grid) error {
 		return err
 	}
 
+	x, y, w, h, err := fr.bounds(g)
+	if err != nil {
+		return err
+	}
+
+	fill := fr.fill
+	stroke := fr.stroke || fr.strokeColor != ""
+
+	// a frame without stroke nor fill is
+	// filled with its color (as always)
+	if fill == "" && fr.gradient == nil && !stroke {
+		fill = fr.color
+	}
+
+	if s := g.tileShadow(fr.shadow); s != nil {
+		lw := 0.5 * fr.strokeWidth
+		err := g.plotShadow(s, x-lw, y-lw, w+2*lw, h+2*lw, func(dc *gg.Context) {
+			fr.path(dc, x, y, w, h)
+			if fill != "" || fr.gradient != nil {
+				dc.Fill()
+				return
+			}
+			dc.SetLineWidth(fr.strokeWidth)
+			dc.Stroke()
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	dc := g.Context()
+
+	dc.Push()
+	defer dc.Pop()
+
+	if fr.gradient != nil {
+		pat, err := fr.gradient.pattern(x, y, w, h)
+		if err != nil {
+			return err
+		}
+
+		fr.path(dc, x, y, w, h)
+		dc.SetFillStyle(pat)
+		dc.Fill()
+	} else if fill != "" {
+		fr.path(dc, x, y, w, h)
+		dc.SetHexColor(fill)
+		dc.Fill()
+	}
+
+	if stroke {
+		if fr.dashes > 0 {
+			dc.SetDash(fr.dashes)
+		} else {
+			dc.SetDash()
+		}
+
+		fr.path(dc, x, y, w, h)
+		dc.SetLineWidth(fr.strokeWidth)
+		dc.SetHexColor(fr.lineColor())
+		dc.Stroke()
+	}
+
+	if fr.title == "" && fr.titleIcon == "" {
+		return nil
+	}
+
+	return fr.plotTitle(g, x, y, w, h)
+}
+
+// bounds returns the frame bounding box (x, y, w, h)
+// according to the alignment and the inset.
+func (fr *Frame) bounds(g *Grid) (float64, float64, float64, float64, error) {
 	p1 := g.CellCenter(fr.Left, fr.Top)
 	p2 := g.CellCenter(fr.Right, fr.Bottom)
-	dx, dy := p2.X-p1.X, p2.Y-p1.Y
+	x1, y1 := math.Min(p1.X, p2.X), math.Min(p1.Y, p2.Y)
+	x2, y2 := math.Max(p1.X, p2.X), math.Max(p1.Y, p2.Y)
 
-	dc := g.Context()
+	cs := g.CellSize()
+
+	// the distance of the edges from the cell centers
+	var d float64
+	switch fr.align {
+	case "", FrameAlignCenter:
+	case FrameAlignOuter:
+		d = 0.5 * cs
+	case FrameAlignInner:
+		d = -0.5 * cs
+	default:
+		return 0, 0, 0, 0, fmt.Errorf("unknown frame alignment: %s", fr.align)
+	}
+
+	d -= fr.inset + fr.insetCells*cs
+
+	x1, y1, x2, y2 = x1-d, y1-d, x2+d, y2+d
+	if x2 < x1 {
+		x1, x2 = 0.5*(x1+x2), 0.5*(x1+x2)
+	}
+	if y2 < y1 {
+		y1, y2 = 0.5*(y1+y2), 0.5*(y1+y2)
+	}
+
+	return x1, y1, x2 - x1, y2 - y1, nil
+}
+
+// path adds the frame shape with the bounding
+// box at (x, y) and size (w, h) to the context.
+func (fr *Frame) path(dc *gg.Context, x, y, w, h float64) {
+	switch {
+	case fr.oval:
+		dc.DrawEllipse(x+0.5*w, y+0.5*h, 0.5*w, 0.5*h)
+	case fr.cornerRadius > 0:
+		dc.DrawRoundedRectangle(x, y, w, h, math.Min(fr.cornerRadius, 0.5*math.Min(w, h)))
+	default:
+		dc.DrawRectangle(x, y, w, h)
+	}
+}
+
+// lineColor returns the stroke color.
+func (fr *Frame) lineColor() string {
+	if fr.strokeColor != "" {
+		return fr.strokeColor
+	}
+
+	return fr.color
+}
+
+// plotTitle draws the title of the frame with
+// the bounding box at (x, y) and size (w, h).
+func (fr *Frame) plotTitle(g *Grid, x, y, w, h float64) error {
+	if fr.titleStyle != TitleBand && fr.titleStyle != TitleTab {
+		return fmt.Errorf("unknown title style: %s", fr.titleStyle)
+	}
 
+	// header height, inner padding and icon size (with its gap)
+	bh := math.Min(0.35*g.CellSize(), h)
+	pad := 0.2 * bh
+	is, iw := 0.0, 0.0
+
+	var icon image.Image
+	if fr.titleIcon != "" {
+		im, err := g.loadImage(fr.titleIcon)
+		if err != nil {
+			return err
+		}
+
+		is = bh - 2*pad
+		icon = imaging.Fit(im, int(is), int(is), imaging.Lanczos)
+
+		iw = is
+		if fr.title != "" {
+			iw += pad
+		}
+	}
+
+	base := textStyle{bold: true, color: fr.titleColor, size: 0.5 * bh}
+	block, err := g.layoutText(parseMarkup(fr.title, base, false), base, 0, 1)
+	if err != nil {
+		return err
+	}
+
+	bx, bw := x, w
+	if fr.titleStyle == TitleTab {
+		bw = math.Min(iw+block.width+2*pad, w)
+		if fr.oval {
+			bx = x + 0.5*(w-bw)
+		}
+	}
+
+	// the title of a band stays inside the frame shape
+	tx, tw := bx, bw
+	if fr.titleStyle == TitleBand {
+		d := fr.shapeInset(w, h, 0.5*(bh-math.Max(block.height, is)))
+		tx, tw = bx+d, math.Max(bw-2*d, 0)
+	}
+
+	if max := tw - 2*pad - iw; block.width > max {
+		block.truncate(math.Max(max, 0), bh)
+	}
+
+	background := fr.titleBackground
+	if background == "" {
+		background = fr.lineColor()
+	}
+
+	dc := g.Context()
 	dc.Push()
-	if fr.dashes > 0 {
-		dc.SetDash(fr.dashes)
-	} else {
-		dc.SetDash()
+	defer dc.Pop()
+
+	if fr.titleStyle == TitleBand && (fr.oval || fr.cornerRadius > 0) {
+		// Pop doesn't restore the clip mask
+		fr.path(dc, x, y, w, h)
+		dc.Clip()
+		defer dc.ResetClip()
 	}
 
-	dc.SetLineWidth(fr.strokeWidth)
-	dc.SetHexColor(fr.color)
+	dc.SetHexColor(background)
+	if fr.titleStyle == TitleTab {
+		dc.DrawRoundedRectangle(bx, y, bw, bh, 0.2*bh)
+	} else {
+		dc.DrawRectangle(bx, y, bw, bh)
+	}
+	dc.Fill()
 
+	// the title is centered on oval frames
+	cx := tx + pad
 	if fr.oval {
-		rx, ry := 0.5*dx, 0.5*dy
-		cx, cy := p1.X+rx, p1.Y+ry
-		dc.DrawEllipse(cx, cy, rx, ry)
-	} else {
-		dc.DrawRectangle(p1.X, p1.Y, dx, dy)
+		cx = tx + 0.5*(tw-iw-block.width)
 	}
 
-	if fr.stroke {
-		dc.Stroke()
-	} else {
-		dc.Fill()
+	if icon != nil {
+		dc.DrawImageAnchored(icon, int(cx+0.5*is), int(y+0.5*bh), 0.5, 0.5)
+		cx += iw
 	}
-	dc.Pop()
+
+	block.draw(dc, cx, y+0.5*(bh-block.height), 0)
 
 	return nil
 }
+
+// shapeInset returns the horizontal space between the bounding
+// box (of size w x h) and the frame shape at the depth d.
+func (fr *Frame) shapeInset(w, h, d float64) float64 {
+	a, b := 0.5*w, 0.5*h
+	r := math.Min(fr.cornerRadius, math.Min(a, b))
+	if fr.oval {
+		r = b
+	}
+
+	if r <= 0 || d >= r {
+		return 0
+	}
+
+	// the distance from the center of the corner
+	dy := (r - math.Max(d, 0)) / r
+	if fr.oval {
+		return a * (1 - math.Sqrt(1-dy*dy))
+	}
+
+	return r * (1 - math.Sqrt(1-dy*dy))
+}
@@ -0,0 +1,169 @@
+package jumble
+
+import (
+	"image/color"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFrameTitle(t *testing.T) {
+	grid, err := NewGrid(4, 4, 64, GridMargin(0))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	fr := NewFrame(0, 0, 3, 3, FrameColor("#0000ff"), FrameTitle("VPC 10.0.0.0/16"))
+	assert.NoError(t, fr.Plot(grid))
+	assert.Empty(t, fr.ImageURIs())
+
+	// the band spans the frame width
+	r, g, b, _ := grid.Context().Image().At(200, 34).RGBA()
+	assert.Equal(t, []uint32{0, 0, 0xffff}, []uint32{r, g, b})
+
+	fr = NewFrame(0, 0, 3, 3, FrameTitle("tab"), FrameTitleStyle(TitleTab), FrameOval(true),
+		FrameTitleIcon("assets://aws_vpc"))
+	assert.NoError(t, fr.Plot(grid))
+	assert.Equal(t, []string{"assets://aws_vpc"}, fr.ImageURIs())
+
+	fr = NewFrame(0, 0, 3, 3, FrameTitle("title"), FrameTitleStyle("ribbon"))
+	assert.Error(t, fr.Plot(grid))
+}
+
+func TestFrameTitleClipReset(t *testing.T) {
+	for _, opt := range []func(*Frame){FrameCornerRadius(8), FrameOval(true)} {
+		grid, err := NewGrid(4, 4, 64, GridMargin(0))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		fr := NewFrame(0, 0, 1, 1, FrameTitle("title"), opt)
+		assert.NoError(t, fr.Plot(grid))
+
+		// the tiles plotted later are not clipped to the frame
+		sh := NewShape(3, 3, ShapeRectangle, ShapeFill("#ff0000"))
+		assert.NoError(t, sh.Plot(grid))
+
+		r, g, b, _ := grid.Context().Image().At(224, 224).RGBA()
+		assert.Equal(t, []uint32{0xffff, 0, 0}, []uint32{r, g, b})
+	}
+}
+
+func TestFrameTitleClip(t *testing.T) {
+	grid, err := NewGrid(4, 4, 64, GridMargin(0))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	im := grid.Context().Image()
+	bg := im.At(0, 0)
+
+	fr := NewFrame(0, 0, 3, 3, FrameOval(true), FrameStroke(false), FrameTitleColor("#ff0000"),
+		FrameTitle("a very long title for such a small oval frame"))
+	assert.NoError(t, fr.Plot(grid))
+
+	x, y, w, h, err := fr.bounds(grid)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// nothing is drawn outside the ellipse
+	a, b := 0.5*w+1, 0.5*h+1
+	for py := int(y); py < int(y+0.35*grid.CellSize()); py++ {
+		for px := int(x); px < int(x+w); px++ {
+			dx, dy := (float64(px)+0.5-x-0.5*w)/a, (float64(py)+0.5-y-0.5*h)/b
+			if dx*dx+dy*dy > 1 && im.At(px, py) != bg {
+				t.Fatalf("pixel (%d, %d) outside the frame shape", px, py)
+			}
+		}
+	}
+}
+
+func TestFrameFillAndStroke(t *testing.T) {
+	grid, err := NewGrid(4, 4, 64, GridMargin(0))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	fr := NewFrame(0, 0, 3, 3, FrameFill("#00ff00"), FrameStrokeColor("#0000ff"),
+		FrameStrokeWidth(4), FrameDashes(0), FrameCornerRadius(10))
+	assert.NoError(t, fr.Plot(grid))
+
+	tests := []struct {
+		x, y    int
+		r, g, b uint32
+	}{
+		{100, 100, 0, 0xffff, 0},         // fill
+		{32, 100, 0, 0, 0xffff},          // stroke
+		{31, 31, 0xffff, 0xffff, 0xffff}, // rounded corner
+	}
+
+	img := grid.Context().Image()
+	for _, tt := range tests {
+		r, g, b, _ := img.At(tt.x, tt.y).RGBA()
+		assert.Equal(t, []uint32{tt.r, tt.g, tt.b}, []uint32{r, g, b}, "pixel (%d, %d)", tt.x, tt.y)
+	}
+}
+
+func TestGradient(t *testing.T) {
+	gr := &Gradient{Colors: []string{"#000", "#ffffff"}}
+	pat, err := gr.pattern(0, 0, 100, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	r0, _, _, _ := pat.ColorAt(0, 5).RGBA()
+	r1, _, _, _ := pat.ColorAt(99, 5).RGBA()
+	assert.True(t, r0 < r1)
+
+	_, err = (&Gradient{Colors: []string{"#000"}}).pattern(0, 0, 10, 10)
+	assert.Error(t, err)
+
+	_, err = (&Gradient{Type: "conic", Colors: []string{"#000", "#fff"}}).pattern(0, 0, 10, 10)
+	assert.Error(t, err)
+
+	_, err = parseHexColor("#zzzzzz")
+	assert.Error(t, err)
+
+	c, err := parseHexColor("#ff000080")
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, color.NRGBA{R: 0xff, A: 0x80}, c)
+}
+
+func TestFrameAlign(t *testing.T) {
+	grid, err := NewGrid(4, 4, 64, GridMargin(0))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		opts []func(*Frame)
+		want []float64
+	}{
+		{nil, []float64{96, 96, 128, 128}},
+		{[]func(*Frame){FrameAlign(FrameAlignOuter)}, []float64{64, 64, 192, 192}},
+		{[]func(*Frame){FrameAlign(FrameAlignInner)}, []float64{128, 128, 64, 64}},
+		{[]func(*Frame){FrameAlign(FrameAlignOuter), FrameInset(4)}, []float64{68, 68, 184, 184}},
+		{[]func(*Frame){FrameAlign(FrameAlignOuter), FrameInset(0.5)}, []float64{64.5, 64.5, 191, 191}},
+		{[]func(*Frame){FrameAlign(FrameAlignOuter), FrameInsetCells(0.25)}, []float64{80, 80, 160, 160}},
+		{[]func(*Frame){FrameAlign(FrameAlignCenter), FrameInsetCells(-0.5)}, []float64{64, 64, 192, 192}},
+	}
+
+	for _, tt := range tests {
+		fr := NewFrame(1, 1, 3, 3, tt.opts...)
+		x, y, w, h, err := fr.bounds(grid)
+		if err != nil {
+			t.Fatal(err)
+		}
+		assert.Equal(t, tt.want, []float64{x, y, w, h})
+	}
+
+	fr := NewFrame(1, 1, 2, 2, FrameAlign(FrameAlignInner))
+	_, _, w, h, _ := fr.bounds(grid)
+	assert.Equal(t, []float64{0, 0}, []float64{w, h})
+
+	fr = NewFrame(1, 1, 3, 3, FrameAlign("middle"))
+	assert.Error(t, fr.Plot(grid))
+}
@@ -0,0 +1,81 @@
+package jumble
+
+import (
+	"fmt"
+	"image/color"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/fogleman/gg"
+)
+
+// Gradient kinds.
+const (
+	// GradientLinear blends the colors along a direction.
+	GradientLinear = "linear"
+	// GradientRadial blends the colors from the center outwards.
+	GradientRadial = "radial"
+)
+
+// Gradient is a gradient fill.
+type Gradient struct {
+	// Type is GradientLinear (default) or GradientRadial.
+	Type string
+	// Colors are the gradient colors (at least two), evenly spaced.
+	Colors []string
+	// Angle is the direction of linear gradients in degree
+	// (0: left to right, 90: top to bottom).
+	Angle float64
+}
+
+// pattern returns the gradient fill of the box at (x, y) and size (w, h).
+func (gr *Gradient) pattern(x, y, w, h float64) (gg.Pattern, error) {
+	if len(gr.Colors) < 2 {
+		return nil, fmt.Errorf("a gradient needs at least two colors")
+	}
+
+	cx, cy := x+0.5*w, y+0.5*h
+
+	var res gg.Gradient
+	switch gr.Type {
+	case "", GradientLinear:
+		a := gg.Radians(gr.Angle)
+		dx, dy := math.Cos(a), math.Sin(a)
+		l := 0.5 * (math.Abs(w*dx) + math.Abs(h*dy))
+		res = gg.NewLinearGradient(cx-l*dx, cy-l*dy, cx+l*dx, cy+l*dy)
+	case GradientRadial:
+		res = gg.NewRadialGradient(cx, cy, 0, cx, cy, 0.5*math.Max(w, h))
+	default:
+		return nil, fmt.Errorf("unknown gradient type: %s", gr.Type)
+	}
+
+	for i, el := range gr.Colors {
+		c, err := parseHexColor(el)
+		if err != nil {
+			return nil, err
+		}
+		res.AddColorStop(float64(i)/float64(len(gr.Colors)-1), c)
+	}
+
+	return res, nil
+}
+
+// parseHexColor parses a #rgb, #rrggbb or #rrggbbaa color
+// (same formats of gg.Context.SetHexColor).
+func parseHexColor(hex string) (color.NRGBA, error) {
+	s := strings.TrimPrefix(hex, "#")
+	if len(s) == 3 {
+		s = string([]byte{s[0], s[0], s[1], s[1], s[2], s[2]})
+	}
+	if len(s) == 6 {
+		s += "ff"
+	}
+
+	v, err := strconv.ParseUint(s, 16, 32)
+	if err != nil || len(s) != 8 {
+		return color.NRGBA{}, fmt.Errorf("invalid color: %s", hex)
+	}
+
+	return color.NRGBA{R: uint8(v >> 24), G: uint8(v >> 16), B: uint8(v >> 8), A: uint8(v)}, nil
+}
@@ -5,11 +5,11 @@ import (
 	"image/png"
 	"io"
 	"os"
+	"sync"
 	"time"
 
 	"github.com/fogleman/gg"
-	"github.com/golang/freetype/truetype"
-	"golang.org/x/image/font/gofont/goregular"
+	"golang.org/x/image/font"
 )
 
 // Tile is anything on the grid
@@ -18,6 +18,35 @@ type Tile interface {
 	Plot(g *Grid) error
 }
 
+// Spanner is implemented by the tiles
+// covering more than one cell.
+type Spanner interface {
+	Span() (rows, cols int)
+}
+
+// Mover is implemented by the tiles that
+// can be moved by some rows and columns.
+type Mover interface {
+	Move(rows, cols int)
+}
+
+// TileError records an error and the tile that caused it.
+type TileError struct {
+	ID  string
+	Err error
+}
+
+// Error implement the standard library
+// interface type for errors.
+func (e *TileError) Error() string {
+	return fmt.Sprintf("tile '%s': %s", e.ID, e.Err.Error())
+}
+
+// Unwrap returns the underlying error.
+func (e *TileError) Unwrap() error {
+	return e.Err
+}
+
 // Grid represents the grid structure
 type Grid struct {
 	cellSize          int
@@ -38,8 +67,13 @@ type Grid struct {
 	imageWidth  int
 	imageHeight int
 
-	font *truetype.Font
-	ctx  *gg.Context
+	fontFamily    string
+	fallbackFonts []string
+	shadow        *Shadow
+	ctx           *gg.Context
+
+	imagesMu sync.Mutex
+	images   map[string]decoded
 }
 
 // NewGrid creates a new grid and sets it up with its configuration
@@ -52,11 +86,6 @@ func NewGrid(rows, cols int, cellSize int, opts ...func(*Grid)) (*Grid, error) {
 		return nil, fmt.Errorf("no columns provided")
 	}
 
-	font, err := truetype.Parse(goregular.TTF)
-	if err != nil {
-		return nil, err
-	}
-
 	res := Grid{
 		rows: rows, cols: cols,
 		cellSize:        cellSize,
@@ -64,13 +93,24 @@ func NewGrid(rows, cols int, cellSize int, opts ...func(*Grid)) (*Grid, error) {
 		lineColor:       "#b8b8a7",
 		backgroundColor: "#ffffff",
 		borderColor:     "#161615",
-		font:            font,
+		fontFamily:      DefaultFont,
+		images:          map[string]decoded{},
 	}
 
 	for _, opt := range opts {
 		opt(&res)
 	}
 
+	if _, err := fontFamily(res.fontFamily); err != nil {
+		return nil, err
+	}
+
+	for _, name := range res.fallbackFonts {
+		if _, err := fontFamily(name); err != nil {
+			return nil, err
+		}
+	}
+
 	res.canvasWidth = res.cellSize * res.cols
 	res.canvasHeight = res.cellSize * res.rows
 	res.imageWidth = res.canvasWidth + 2*res.margin
@@ -209,7 +249,10 @@ func (g *Grid) DrawCoords() {
 	cs := g.CellSize()
 	fontSize := 0.3 * cs
 
-	face := truetype.NewFace(g.font, &truetype.Options{Size: fontSize})
+	face, err := g.fontFace("", false, false, fontSize)
+	if err != nil {
+		return
+	}
 
 	g.ctx.Push()
 	g.ctx.SetFontFace(face)
@@ -232,6 +275,32 @@ func (g *Grid) DrawCoords() {
 	g.ctx.Pop()
 }
 
+// fontFace returns a face of the specified font family, style
+// and size; an empty family means the grid default font.
+func (g *Grid) fontFace(family string, bold, italic bool, size float64) (font.Face, error) {
+	f, err := g.font(family, bold, italic)
+	if err != nil {
+		return nil, err
+	}
+
+	return f.Face(size), nil
+}
+
+// font returns the font of the specified family and style;
+// an empty family means the grid default font.
+func (g *Grid) font(family string, bold, italic bool) (*Font, error) {
+	if family == "" {
+		family = g.fontFamily
+	}
+
+	fam, err := fontFamily(family)
+	if err != nil {
+		return nil, err
+	}
+
+	return fam.Variant(bold, italic), nil
+}
+
 // CellSize returns the cell dimension
 func (g *Grid) CellSize() float64 {
 	return float64(g.cellSize)
@@ -269,3 +338,21 @@ func GridMargin(val int) func(*Grid) {
 		g.margin = val
 	}
 }
+
+// GridFallbackFonts sets the font families used (in order)
+// for the label characters missing in the label font.
+func GridFallbackFonts(names ...string) func(*Grid) {
+	return func(g *Grid) {
+		g.fallbackFonts = names
+	}
+}
+
+// GridFont sets the default font family
+// of labels and hints.
+func GridFont(name string) func(*Grid) {
+	return func(g *Grid) {
+		if name != "" {
+			g.fontFamily = name
+		}
+	}
+}
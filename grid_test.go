@@ -3,6 +3,8 @@ package jumble
 import (
 	"bytes"
 	"encoding/base64"
+	"io/ioutil"
+	"os"
 	"path/filepath"
 	"strings"
 	"testing"
@@ -85,7 +87,7 @@ func TestGridVerifyInBounds(t *testing.T) {
 }
 
 func TestGridLayout(t *testing.T) {
-	grid, err := NewGrid(4, 4, 12, GridTitle("test"))
+	grid, err := NewGrid(4, 4, 12)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -101,11 +103,11 @@ func TestGridLayout(t *testing.T) {
 
 	str := base64.StdEncoding.EncodeToString(data.Bytes())
 	//t.Logf(str)
-	assert.True(t, strings.HasPrefix(str, "iVBORw0KGgoAAAANSUhEUgAAAGAAAABgCAIAAABt+uBvAAAErElEQVR4Aeyb3U7qShiG"))
+	assert.True(t, strings.HasPrefix(str, "iVBORw0KGgoAAAANSUhEUgAAAGAAAABgCAIAAABt+uBvAAAEQklEQVR4AeybX1OjOhyGE0iH0gPUcla/"))
 }
 
 func TestGrid(t *testing.T) {
-	grid, err := NewGrid(12, 10, 72, GridTitle("test"))
+	grid, err := NewGrid(12, 10, 72)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -113,19 +115,19 @@ func TestGrid(t *testing.T) {
 	iconsPath := "/home/lus/Pictures/AWS-Architecture-Icons/PNG"
 
 	icons := []Icon{
-		{Row: 9, Col: 4, Fit: true, URI: filepath.Join(iconsPath, "aws_api_gateway.png")},
-		{Row: 8, Col: 2, Fit: true, URI: filepath.Join(iconsPath, "aws_lambda.png")},
-		{Row: 10, Col: 2, Fit: true, URI: filepath.Join(iconsPath, "aws_lambda.png")},
+		{Row: 9, Col: 4, Fit: FitContain, URI: filepath.Join(iconsPath, "aws_api_gateway.png")},
+		{Row: 8, Col: 2, Fit: FitContain, URI: filepath.Join(iconsPath, "aws_lambda.png")},
+		{Row: 10, Col: 2, Fit: FitContain, URI: filepath.Join(iconsPath, "aws_lambda.png")},
 
-		{Row: 6, Col: 2, Fit: true, URI: filepath.Join(iconsPath, "aws_rds_mysql_instance.png")},
-		{Row: 5, Col: 2, Fit: true, URI: filepath.Join(iconsPath, "aws_simple_storage_service_s3_bucket.png")},
-		{Row: 4, Col: 2, Fit: true, URI: filepath.Join(iconsPath, "aws_elasticache_for_redis.png")},
+		{Row: 6, Col: 2, Fit: FitContain, URI: filepath.Join(iconsPath, "aws_rds_mysql_instance.png")},
+		{Row: 5, Col: 2, Fit: FitContain, URI: filepath.Join(iconsPath, "aws_simple_storage_service_s3_bucket.png")},
+		{Row: 4, Col: 2, Fit: FitContain, URI: filepath.Join(iconsPath, "aws_elasticache_for_redis.png")},
 
-		{Row: 5, Col: 4, Fit: true, URI: filepath.Join(iconsPath, "aws_elastic_container_service.png")},
-		{Row: 2, Col: 4, Fit: true, URI: filepath.Join(iconsPath, "aws_vpc_vpn_connection.png")},
-		{Row: 5, Col: 8, Fit: true, URI: filepath.Join(iconsPath, "aws_simple_notification_service_sns.png")},
-		{Row: 3, Col: 8, Fit: true, URI: filepath.Join(iconsPath, "aws_simple_notification_service_sns_topic.png")},
-		{Row: 5, Col: 6, Fit: true, URI: filepath.Join(iconsPath, "aws_vpc_elastic_network_interface.png")},
+		{Row: 5, Col: 4, Fit: FitContain, URI: filepath.Join(iconsPath, "aws_elastic_container_service.png")},
+		{Row: 2, Col: 4, Fit: FitContain, URI: filepath.Join(iconsPath, "aws_vpc_vpn_connection.png")},
+		{Row: 5, Col: 8, Fit: FitContain, URI: filepath.Join(iconsPath, "aws_simple_notification_service_sns.png")},
+		{Row: 3, Col: 8, Fit: FitContain, URI: filepath.Join(iconsPath, "aws_simple_notification_service_sns_topic.png")},
+		{Row: 5, Col: 6, Fit: FitContain, URI: filepath.Join(iconsPath, "aws_vpc_elastic_network_interface.png")},
 	}
 
 	connectors := []Connector{
@@ -187,5 +189,13 @@ func TestGrid(t *testing.T) {
 		el.Plot(grid)
 	}
 
-	grid.SavePNG()
+	dir, err := ioutil.TempDir("", "jumble")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	if err := grid.SavePNG(filepath.Join(dir, "grid.png")); err != nil {
+		t.Fatal(err)
+	}
 }
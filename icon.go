@@ -1,15 +1,48 @@
 package jumble
 
 import (
+	"fmt"
+	"image"
+	"math"
+
 	"github.com/disintegration/imaging"
+	"github.com/fogleman/gg"
+)
+
+// Icon fit modes.
+const (
+	// FitContain scales the image to fit the cell keeping the aspect ratio.
+	FitContain = "contain"
+	// FitCover scales the image to fill the cell cropping the exceeding part.
+	FitCover = "cover"
+	// FitStretch scales the image to fill the cell ignoring the aspect ratio.
+	FitStretch = "stretch"
+	// FitNone draws the image at its original size.
+	FitNone = "none"
 )
 
 // Icon wraps an image.
 type Icon struct {
 	Row int
 	Col int
-	Fit bool
-	URI string
+	// Fit is the scaling mode (default: FitContain).
+	Fit string
+	// Padding is the space around the image
+	// as a percentage of the cell size.
+	Padding float64
+	// Anchor aligns the image inside the cell
+	// (center, top, top_right, right, ...).
+	Anchor string
+	URI    string
+	// Badge is an optional marker pinned to a corner.
+	Badge *Badge
+	// Overlay is an optional symbol drawn
+	// over the image (strike, cross, lock).
+	Overlay      string
+	OverlayColor string
+	// Shadow is an optional drop shadow
+	// (nil for the grid default one).
+	Shadow *Shadow
 }
 
 // NewIcon returns a new icon from the specified uri
@@ -17,7 +50,7 @@ func NewIcon(row, col int, uri string) Icon {
 	return Icon{
 		Row: row, Col: col,
 		URI: uri,
-		Fit: true,
+		Fit: FitContain,
 	}
 }
 
@@ -26,36 +59,155 @@ func (ic *Icon) Location() (int, int) {
 	return ic.Row, ic.Col
 }
 
+// Move moves the icon by some rows and columns
+func (ic *Icon) Move(rows, cols int) {
+	ic.Row += rows
+	ic.Col += cols
+}
+
+// ImageURIs returns the URI of the image
+func (ic *Icon) ImageURIs() []string {
+	return []string{ic.URI}
+}
+
 // Plot draws a image (eventually rescaling) in a cell.
 func (ic *Icon) Plot(g *Grid) error {
 	if err := g.VerifyInBounds(ic.Row, ic.Col); err != nil {
 		return err
 	}
 
-	im, err := LoadImage(ic.URI)
+	ax, ay, err := anchorPoint(ic.Anchor)
 	if err != nil {
 		return err
 	}
 
-	if ic.Fit {
-		b := im.Bounds()
-		size := b.Max.X
-		if b.Max.Y > size {
-			size = b.Max.Y
-		}
+	im, err := g.loadImage(ic.URI)
+	if err != nil {
+		return err
+	}
 
-		if g.cellSize < size {
-			im = imaging.Resize(im, g.cellSize, g.cellSize, imaging.Lanczos)
-		}
+	cs := g.CellSize()
+	pad := cs * math.Max(0, math.Min(ic.Padding, 50)) / 100
+	box := int(math.Max(1, math.Round(cs-2*pad)))
+
+	im, err = fitImage(im, ic.Fit, box, ic.Anchor)
+	if err != nil {
+		return err
 	}
 
 	center := g.CellCenter(ic.Row, ic.Col)
+	x := center.X - 0.5*cs + pad + ax*float64(box)
+	y := center.Y - 0.5*cs + pad + ay*float64(box)
+
+	if s := g.tileShadow(ic.Shadow); s != nil {
+		b := im.Bounds()
+		ix, iy := float64(int(x))-ax*float64(b.Dx()), float64(int(y))-ay*float64(b.Dy())
+		err := g.plotShadow(s, ix, iy, float64(b.Dx()), float64(b.Dy()), func(dc *gg.Context) {
+			dc.DrawImageAnchored(im, int(x), int(y), ax, ay)
+		})
+		if err != nil {
+			return err
+		}
+	}
 
 	dc := g.Context()
 	dc.Push()
 	//g.ctx.RotateAbout(gg.Radians(alpha), center.X, center.Y)
-	dc.DrawImageAnchored(im, int(center.X), int(center.Y), 0.5, 0.5)
+	dc.DrawImageAnchored(im, int(x), int(y), ax, ay)
 	dc.Pop()
 
+	if ic.Overlay != "" {
+		if err := plotOverlay(g, center, ic.Overlay, ic.OverlayColor); err != nil {
+			return err
+		}
+	}
+
+	if ic.Badge != nil {
+		return ic.Badge.plot(g, center)
+	}
+
 	return nil
 }
+
+// fitImage scales the image into a square box of the specified size.
+func fitImage(im image.Image, mode string, box int, anchor string) (image.Image, error) {
+	b := im.Bounds()
+	w, h := float64(b.Dx()), float64(b.Dy())
+	if w == 0 || h == 0 {
+		return im, nil
+	}
+
+	switch mode {
+	case "", FitContain:
+		scale := math.Min(float64(box)/w, float64(box)/h)
+		if scale == 1 {
+			return im, nil
+		}
+		nw := int(math.Max(1, math.Round(w*scale)))
+		nh := int(math.Max(1, math.Round(h*scale)))
+		return imaging.Resize(im, nw, nh, imaging.Lanczos), nil
+
+	case FitCover:
+		return imaging.Fill(im, box, box, imagingAnchor(anchor), imaging.Lanczos), nil
+
+	case FitStretch:
+		return imaging.Resize(im, box, box, imaging.Lanczos), nil
+
+	case FitNone:
+		return im, nil
+	}
+
+	return nil, fmt.Errorf("unknown fit mode: %s", mode)
+}
+
+// anchorPoint returns the relative
+// coordinates of the named anchor.
+func anchorPoint(name string) (float64, float64, error) {
+	switch name {
+	case "", "center":
+		return 0.5, 0.5, nil
+	case "top":
+		return 0.5, 0, nil
+	case "top_right":
+		return 1, 0, nil
+	case "right":
+		return 1, 0.5, nil
+	case "bottom_right":
+		return 1, 1, nil
+	case "bottom":
+		return 0.5, 1, nil
+	case "bottom_left":
+		return 0, 1, nil
+	case "left":
+		return 0, 0.5, nil
+	case "top_left":
+		return 0, 0, nil
+	}
+
+	return 0, 0, fmt.Errorf("unknown anchor: %s", name)
+}
+
+// imagingAnchor converts the named anchor to the
+// imaging one used when cropping the image.
+func imagingAnchor(name string) imaging.Anchor {
+	switch name {
+	case "top":
+		return imaging.Top
+	case "top_right":
+		return imaging.TopRight
+	case "right":
+		return imaging.Right
+	case "bottom_right":
+		return imaging.BottomRight
+	case "bottom":
+		return imaging.Bottom
+	case "bottom_left":
+		return imaging.BottomLeft
+	case "left":
+		return imaging.Left
+	case "top_left":
+		return imaging.TopLeft
+	}
+
+	return imaging.Center
+}
@@ -0,0 +1,68 @@
+package jumble
+
+import (
+	"image"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFitImage(t *testing.T) {
+	tests := []struct {
+		mode string
+		w, h int
+		want image.Rectangle
+	}{
+		{FitContain, 200, 100, image.Rect(0, 0, 64, 32)},
+		{FitContain, 16, 32, image.Rect(0, 0, 32, 64)},
+		{FitCover, 200, 100, image.Rect(0, 0, 64, 64)},
+		{FitStretch, 200, 100, image.Rect(0, 0, 64, 64)},
+		{FitNone, 200, 100, image.Rect(0, 0, 200, 100)},
+		{"", 64, 64, image.Rect(0, 0, 64, 64)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.mode, func(t *testing.T) {
+			src := image.NewRGBA(image.Rect(0, 0, tt.w, tt.h))
+			got, err := fitImage(src, tt.mode, 64, "")
+			if err != nil {
+				t.Fatal(err)
+			}
+			assert.Equal(t, tt.want, got.Bounds())
+		})
+	}
+
+	_, err := fitImage(image.NewRGBA(image.Rect(0, 0, 1, 1)), "squash", 64, "")
+	assert.Error(t, err)
+}
+
+func TestAnchorPoint(t *testing.T) {
+	ax, ay, err := anchorPoint("bottom_right")
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, 1.0, ax)
+	assert.Equal(t, 1.0, ay)
+
+	_, _, err = anchorPoint("middle_earth")
+	assert.Error(t, err)
+}
+
+func TestIconBadgeAndOverlay(t *testing.T) {
+	grid, err := NewGrid(2, 2, 32)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ic := NewIcon(0, 0, "assets://aws_lambda")
+	ic.Overlay = OverlayLock
+	ic.Badge = &Badge{Text: "3"}
+	assert.NoError(t, ic.Plot(grid))
+
+	ic.Badge.Corner = "middle"
+	assert.Error(t, ic.Plot(grid))
+
+	ic.Badge = nil
+	ic.Overlay = "smile"
+	assert.Error(t, ic.Plot(grid))
+}
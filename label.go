@@ -1,8 +1,10 @@
 package jumble
 
 import (
+	"fmt"
+	"math"
+
 	"github.com/fogleman/gg"
-	"github.com/golang/freetype/truetype"
 )
 
 // Label wraps a string.
@@ -14,14 +16,45 @@ type Label struct {
 	color      string
 	background string
 	angle      float64
+
+	maxWidth    float64
+	lineSpacing float64
+	textAlign   string
+
+	fontFamily string
+	bold       bool
+	italic     bool
+
+	align   string
+	valign  string
+	offsetX float64
+	offsetY float64
+
+	markup bool
+
+	rowSpan     int
+	colSpan     int
+	autoSize    bool
+	minFontSize float64
+
+	padding      float64
+	cornerRadius float64
+	borderColor  string
+	borderWidth  float64
+	shadow       *Shadow
 }
 
 // NewLabel returns a new label
 func NewLabel(row, col int, text string, opts ...func(*Label)) Label {
 	res := Label{
 		Row: row, Col: col,
-		text:  text,
-		color: "#000000",
+		text:         text,
+		color:        "#000000",
+		lineSpacing:  1,
+		rowSpan:      1,
+		colSpan:      1,
+		padding:      -1,
+		cornerRadius: 2,
 	}
 
 	for _, opt := range opts {
@@ -59,44 +92,368 @@ func LabelAngle(val float64) func(*Label) {
 	}
 }
 
+// LabelMaxWidth sets the max width (in cells)
+// after which the text is wrapped
+func LabelMaxWidth(val float64) func(*Label) {
+	return func(lab *Label) {
+		lab.maxWidth = val
+	}
+}
+
+// LabelLineSpacing sets the lines spacing
+// (as a multiple of the font height)
+func LabelLineSpacing(val float64) func(*Label) {
+	return func(lab *Label) {
+		lab.lineSpacing = val
+	}
+}
+
+// LabelTextAlign sets the lines alignment (left, center, right);
+// by default the lines follow the label alignment
+func LabelTextAlign(val string) func(*Label) {
+	return func(lab *Label) {
+		lab.textAlign = val
+	}
+}
+
+// LabelFont sets the label font family
+func LabelFont(name string) func(*Label) {
+	return func(lab *Label) {
+		lab.fontFamily = name
+	}
+}
+
+// LabelBold enables the bold font variant
+func LabelBold(val bool) func(*Label) {
+	return func(lab *Label) {
+		lab.bold = val
+	}
+}
+
+// LabelItalic enables the italic font variant
+func LabelItalic(val bool) func(*Label) {
+	return func(lab *Label) {
+		lab.italic = val
+	}
+}
+
+// LabelAlign sets the label horizontal
+// alignment in the cell (left, center, right)
+func LabelAlign(val string) func(*Label) {
+	return func(lab *Label) {
+		lab.align = val
+	}
+}
+
+// LabelVAlign sets the label vertical
+// alignment in the cell (top, middle, bottom)
+func LabelVAlign(val string) func(*Label) {
+	return func(lab *Label) {
+		lab.valign = val
+	}
+}
+
+// LabelOffset moves the label by a fraction of cells
+func LabelOffset(x, y float64) func(*Label) {
+	return func(lab *Label) {
+		lab.offsetX, lab.offsetY = x, y
+	}
+}
+
+// LabelMarkup enables the inline markup:
+// **bold**, *italic*, `monospace` and
+// [text]{color=#888888 size=0.8} spans
+func LabelMarkup(val bool) func(*Label) {
+	return func(lab *Label) {
+		lab.markup = val
+	}
+}
+
+// LabelRowSpan sets the number of rows covered by the label
+func LabelRowSpan(val int) func(*Label) {
+	return func(lab *Label) {
+		lab.rowSpan = val
+	}
+}
+
+// LabelColSpan sets the number of columns covered by the label
+func LabelColSpan(val int) func(*Label) {
+	return func(lab *Label) {
+		lab.colSpan = val
+	}
+}
+
+// LabelAutoFontSize shrinks the font size until the text
+// fits the label box (but not below the min size, 0 for
+// the default); the text still overflowing is ellipsized
+func LabelAutoFontSize(min float64) func(*Label) {
+	return func(lab *Label) {
+		lab.autoSize = true
+		lab.minFontSize = min
+	}
+}
+
+// LabelPadding sets the space (in pixels) between the text
+// and the box border; by default it depends on the font size
+func LabelPadding(val float64) func(*Label) {
+	return func(lab *Label) {
+		lab.padding = val
+	}
+}
+
+// LabelCornerRadius sets the box corner radius
+func LabelCornerRadius(val float64) func(*Label) {
+	return func(lab *Label) {
+		lab.cornerRadius = val
+	}
+}
+
+// LabelBorder sets the box border color and width
+func LabelBorder(hex string, width float64) func(*Label) {
+	return func(lab *Label) {
+		lab.borderColor, lab.borderWidth = hex, width
+	}
+}
+
+// LabelShadow sets the box drop shadow
+func LabelShadow(val *Shadow) func(*Label) {
+	return func(lab *Label) {
+		lab.shadow = val
+	}
+}
+
 // Location returns the grid position (row, col)
 func (lab *Label) Location() (int, int) {
 	return lab.Row, lab.Col
 }
 
-// Plot draws a string in a cell
+// Move moves the label by some rows and columns
+func (lab *Label) Move(rows, cols int) {
+	lab.Row += rows
+	lab.Col += cols
+}
+
+// Span returns the number of rows and columns covered by the label
+func (lab *Label) Span() (int, int) {
+	return lab.rowSpan, lab.colSpan
+}
+
+// Plot draws a string in a cell (or in the spanned cells)
 func (lab *Label) Plot(g *Grid) error {
+	if lab.rowSpan < 1 || lab.colSpan < 1 {
+		return fmt.Errorf("invalid label span: %d x %d", lab.rowSpan, lab.colSpan)
+	}
+
 	if err := g.VerifyInBounds(lab.Row, lab.Col); err != nil {
 		return err
 	}
 
+	if err := g.VerifyInBounds(lab.Row+lab.rowSpan-1, lab.Col+lab.colSpan-1); err != nil {
+		return err
+	}
+
+	ax, err := textAnchor(lab.align)
+	if err != nil {
+		return err
+	}
+
+	ay, err := verticalAnchor(lab.valign)
+	if err != nil {
+		return err
+	}
+
+	textAlign := lab.textAlign
+	if textAlign == "" {
+		textAlign = lab.align
+	}
+
+	tx, err := textAnchor(textAlign)
+	if err != nil {
+		return err
+	}
+
 	if lab.fontSize == 0 {
 		lab.fontSize = 0.3 * float64(g.cellSize)
 	}
 
-	face := truetype.NewFace(g.font, &truetype.Options{Size: lab.fontSize})
+	spacing := lab.lineSpacing
+	if spacing <= 0 {
+		spacing = 1
+	}
+
+	cs := g.CellSize()
+
+	// the label box
+	first := g.CellCenter(lab.Row, lab.Col)
+	last := g.CellCenter(lab.Row+lab.rowSpan-1, lab.Col+lab.colSpan-1)
+	center := gg.Point{X: 0.5 * (first.X + last.X), Y: 0.5 * (first.Y + last.Y)}
+	boxW, boxH := float64(lab.colSpan)*cs, float64(lab.rowSpan)*cs
+
+	block, err := lab.layout(g, boxW, boxH, spacing)
+	if err != nil {
+		return err
+	}
 
-	center := g.CellCenter(lab.Row, lab.Col)
+	// the anchor point in the box (also the rotation pivot)
+	px := center.X + (ax-0.5)*boxW + lab.offsetX*cs
+	py := center.Y + (ay-0.5)*boxH + lab.offsetY*cs
 
 	dc := g.Context()
+	dc.Push()
+	defer dc.Pop()
 
-	if lab.background != "" {
-		sw, sh := g.ctx.MeasureString(lab.text)
-		pad := lab.fontSize
+	// top left corner of the text block
+	sw, sh := block.width, block.height
+	bx, by := px-ax*sw, py-ay*sh
 
-		dc.Push()
-		dc.SetHexColor(lab.background)
-		dc.DrawRoundedRectangle(center.X-(pad+sw/2), center.Y-(pad+sh)/2, sw+2*pad, sh+pad, 2)
-		dc.Fill()
-		dc.Pop()
+	dc.RotateAbout(gg.Radians(lab.angle), px, py)
+
+	if err := lab.plotBox(g, bx, by, sw, sh, block.size); err != nil {
+		return err
 	}
 
-	dc.Push()
-	dc.SetFontFace(face)
 	dc.SetHexColor(lab.color)
-	dc.RotateAbout(gg.Radians(lab.angle), center.X, center.Y)
-	dc.DrawStringAnchored(lab.text, center.X, center.Y, 0.5, 0.5)
-	dc.Pop()
+	block.draw(dc, bx, by, tx)
 
 	return nil
 }
+
+// plotBox draws the box (shadow, background and border) around
+// the text block with the top left corner at (x, y) and size (w, h).
+func (lab *Label) plotBox(g *Grid, x, y, w, h, fontSize float64) error {
+	border := lab.borderColor != "" && lab.borderWidth > 0
+	if lab.background == "" && !border {
+		return nil
+	}
+
+	padX, padY := lab.boxPadding(fontSize)
+	x, y = x-padX, y-padY
+	w, h = w+2*padX, h+2*padY
+	r := math.Min(math.Max(lab.cornerRadius, 0), 0.5*math.Min(w, h))
+
+	if s := g.tileShadow(lab.shadow); s != nil {
+		err := g.plotShadow(s, x, y, w, h, func(dc *gg.Context) {
+			dc.DrawRoundedRectangle(x, y, w, h, r)
+			dc.Fill()
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	dc := g.Context()
+	dc.Push()
+	defer dc.Pop()
+
+	dc.DrawRoundedRectangle(x, y, w, h, r)
+	if lab.background != "" {
+		dc.SetHexColor(lab.background)
+		if border {
+			dc.FillPreserve()
+		} else {
+			dc.Fill()
+		}
+	}
+
+	if border {
+		dc.SetHexColor(lab.borderColor)
+		dc.SetLineWidth(lab.borderWidth)
+		dc.Stroke()
+	}
+
+	return nil
+}
+
+// boxPadding returns the horizontal and vertical space between
+// the text and the box border (zero when there is no box).
+func (lab *Label) boxPadding(fontSize float64) (float64, float64) {
+	if lab.background == "" && (lab.borderColor == "" || lab.borderWidth <= 0) {
+		return 0, 0
+	}
+
+	if lab.padding < 0 {
+		return fontSize, 0.5 * fontSize
+	}
+
+	return lab.padding, lab.padding
+}
+
+// layout lays out the label text; in auto mode the font size is
+// reduced (down to the min size) until the text and the box
+// padding fit the box (w x h).
+func (lab *Label) layout(g *Grid, w, h, spacing float64) (textBlock, error) {
+	size, minSize := lab.fontSize, lab.minFontSize
+	if lab.autoSize {
+		size = math.Max(0.3*g.CellSize(), minSize)
+	}
+
+	if minSize <= 0 {
+		minSize = math.Min(size, 0.1*g.CellSize())
+	}
+
+	for {
+		base := textStyle{
+			family: lab.fontFamily,
+			bold:   lab.bold,
+			italic: lab.italic,
+			color:  lab.color,
+			size:   size,
+		}
+
+		// the space left by the box padding
+		padX, padY := lab.boxPadding(size)
+		fw, fh := math.Max(w-2*padX, 0), math.Max(h-2*padY, 0)
+
+		maxWidth := lab.maxWidth * g.CellSize()
+		if lab.autoSize && (maxWidth <= 0 || maxWidth > fw) {
+			maxWidth = fw
+		}
+
+		res, err := g.layoutText(parseMarkup(lab.text, base, lab.markup), base, maxWidth, spacing)
+		if err != nil {
+			return textBlock{}, err
+		}
+		res.size = size
+
+		if !lab.autoSize || (res.width <= fw && res.height <= fh) {
+			return res, nil
+		}
+
+		if size <= minSize {
+			res.truncate(fw, fh)
+			return res, nil
+		}
+
+		size = math.Max(0.9*size, minSize)
+	}
+}
+
+// verticalAnchor returns the vertical
+// anchor of the label alignment.
+func verticalAnchor(valign string) (float64, error) {
+	switch valign {
+	case "top":
+		return 0, nil
+	case "", "middle":
+		return 0.5, nil
+	case "bottom":
+		return 1, nil
+	}
+
+	return 0, fmt.Errorf("unknown vertical alignment: %s", valign)
+}
+
+// textAnchor returns the horizontal
+// anchor of the text alignment.
+func textAnchor(align string) (float64, error) {
+	switch align {
+	case "left":
+		return 0, nil
+	case "", "center":
+		return 0.5, nil
+	case "right":
+		return 1, nil
+	}
+
+	return 0, fmt.Errorf("unknown alignment: %s", align)
+}
@@ -0,0 +1,182 @@
+package jumble
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/fogleman/gg"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLabelLines(t *testing.T) {
+	grid, err := NewGrid(4, 4, 64)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		text     string
+		maxWidth float64
+		want     []string
+	}{
+		{"one line", 0, []string{"one line"}},
+		{"first\nsecond", 0, []string{"first", "second"}},
+		{"first\n\nthird", 0, []string{"first", "", "third"}},
+		{"wrap this rather long text", 64, []string{"wrap this", "rather long", "text"}},
+	}
+
+	base := textStyle{size: 12}
+	for _, tt := range tests {
+		t.Run(tt.text, func(t *testing.T) {
+			block, err := grid.layoutText(parseMarkup(tt.text, base, false), base, tt.maxWidth, 1)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			got := []string{}
+			for _, line := range block.lines {
+				text := ""
+				for _, run := range line.runs {
+					text += run.text
+				}
+				got = append(got, text)
+			}
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestParseMarkup(t *testing.T) {
+	base := textStyle{color: "#000000", size: 10}
+
+	bold, italic, mono := base, base, base
+	bold.bold, italic.italic, mono.mono = true, true, true
+
+	grey := base
+	grey.color, grey.size = "#888888", 7
+
+	tests := []struct {
+		text string
+		want [][]textRun
+	}{
+		{"plain", [][]textRun{{{text: "plain", style: base}}}},
+		{"**Orders API** v2", [][]textRun{{
+			{text: "Orders API", style: bold}, {text: " v2", style: base},
+		}}},
+		{"*a* `b`", [][]textRun{{
+			{text: "a", style: italic}, {text: " ", style: base}, {text: "b", style: mono},
+		}}},
+		{"API\n[v2 internal]{color=#888888 size=0.7}", [][]textRun{
+			{{text: "API", style: base}},
+			{{text: "v2 internal", style: grey}},
+		}},
+		{`2 * 3 \*\* [x]`, [][]textRun{{{text: "2 * 3 ** [x]", style: base}}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.text, func(t *testing.T) {
+			assert.Equal(t, tt.want, parseMarkup(tt.text, base, true))
+		})
+	}
+
+	assert.Equal(t, [][]textRun{{{text: "**raw**", style: base}}},
+		parseMarkup("**raw**", base, false))
+}
+
+func TestLabelPlot(t *testing.T) {
+	grid, err := NewGrid(4, 4, 64)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	lab := NewLabel(1, 1, "multi\nline", LabelTextAlign("left"), LabelBackground("#ff0000"))
+	assert.NoError(t, lab.Plot(grid))
+
+	lab = NewLabel(1, 1, "text", LabelTextAlign("justify"))
+	assert.Error(t, lab.Plot(grid))
+
+	lab = NewLabel(1, 1, "text", LabelAlign("right"), LabelVAlign("top"), LabelOffset(0.1, 0.2), LabelAngle(45))
+	assert.NoError(t, lab.Plot(grid))
+
+	lab = NewLabel(1, 1, "**bold** [grey]{color=#888888 size=0.7}", LabelMarkup(true),
+		LabelBackground("#ffff00"), LabelAngle(-30), LabelMaxWidth(1))
+	assert.NoError(t, lab.Plot(grid))
+
+	lab = NewLabel(1, 1, "text", LabelVAlign("center"))
+	assert.Error(t, lab.Plot(grid))
+}
+
+func TestLabelAutoFontSize(t *testing.T) {
+	grid, err := NewGrid(4, 4, 64)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	lab := NewLabel(0, 0, "a rather long section title", LabelColSpan(2), LabelAutoFontSize(0))
+	block, err := lab.layout(grid, 128, 24, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.True(t, block.width <= 128 && block.height <= 24)
+	assert.True(t, block.size < 0.3*64)
+
+	lab = NewLabel(0, 0, "this text can't fit at the min size", LabelAutoFontSize(16))
+	block, err = lab.layout(grid, 64, 20, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, 16.0, block.size)
+	assert.Len(t, block.lines, 1)
+	assert.True(t, block.width <= 64)
+
+	last := block.lines[0].runs[len(block.lines[0].runs)-1]
+	assert.True(t, strings.HasSuffix(last.text, ellipsis))
+
+	// the min size is larger than the default start size
+	lab = NewLabel(0, 0, "big", LabelAutoFontSize(24))
+	block, err = lab.layout(grid, 64, 64, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, 24.0, block.size)
+
+	// the text fits the box with the padding
+	lab = NewLabel(0, 0, "a rather long section title", LabelColSpan(2),
+		LabelAutoFontSize(0), LabelBackground("#ff0000"))
+	block, err = lab.layout(grid, 128, 64, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	padX, padY := lab.boxPadding(block.size)
+	assert.True(t, block.width+2*padX <= 128 && block.height+2*padY <= 64)
+
+	lab = NewLabel(3, 3, "out", LabelColSpan(2))
+	assert.Error(t, lab.Plot(grid))
+}
+
+func TestLabelBox(t *testing.T) {
+	grid, err := NewGrid(4, 4, 64, GridMargin(0))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dc := grid.Context()
+	lab := NewLabel(0, 0, "box", LabelBackground("#00ff00"), LabelPadding(4), LabelCornerRadius(0),
+		LabelBorder("#0000ff", 2), LabelShadow(&Shadow{Offset: &gg.Point{X: 10, Y: 10}, Color: "#ff0000"}))
+	assert.NoError(t, lab.plotBox(grid, 10, 10, 20, 20, 12))
+
+	tests := []struct {
+		x, y    int
+		r, g, b uint32
+	}{
+		{20, 20, 0, 0xffff, 0},         // background
+		{6, 20, 0, 0, 0xffff},          // border
+		{40, 40, 0xffff, 0, 0},         // shadow
+		{3, 3, 0xffff, 0xffff, 0xffff}, // outside
+	}
+
+	for _, tt := range tests {
+		r, g, b, _ := dc.Image().At(tt.x, tt.y).RGBA()
+		assert.Equal(t, []uint32{tt.r, tt.g, tt.b}, []uint32{r, g, b}, "pixel (%d, %d)", tt.x, tt.y)
+	}
+}
@@ -0,0 +1,76 @@
+package jumble
+
+import (
+	"image"
+	"sync"
+)
+
+// ImageSource is implemented by the tiles that draw images.
+type ImageSource interface {
+	ImageURIs() []string
+}
+
+// decoded is the outcome of loading an image.
+type decoded struct {
+	im  image.Image
+	err error
+}
+
+// Prefetch concurrently loads and decodes all the distinct images
+// used by the tiles, with a pool of the specified number of workers.
+// Loading errors are reported when the tile is plotted.
+func (g *Grid) Prefetch(workers int, tiles ...Tile) {
+	if workers <= 0 {
+		workers = 4
+	}
+
+	seen := map[string]bool{}
+	uris := make(chan string)
+	go func() {
+		defer close(uris)
+		for _, t := range tiles {
+			src, ok := t.(ImageSource)
+			if !ok {
+				continue
+			}
+			for _, uri := range src.ImageURIs() {
+				if uri == "" || seen[uri] {
+					continue
+				}
+				seen[uri] = true
+				uris <- uri
+			}
+		}
+	}()
+
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for uri := range uris {
+				g.loadImage(uri)
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+// loadImage returns the image at the specified URI
+// decoding it only the first time it is requested.
+func (g *Grid) loadImage(uri string) (image.Image, error) {
+	g.imagesMu.Lock()
+	res, ok := g.images[uri]
+	g.imagesMu.Unlock()
+	if ok {
+		return res.im, res.err
+	}
+
+	im, err := LoadImage(uri)
+
+	g.imagesMu.Lock()
+	g.images[uri] = decoded{im: im, err: err}
+	g.imagesMu.Unlock()
+
+	return im, err
+}
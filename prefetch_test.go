@@ -0,0 +1,52 @@
+package jumble
+
+import (
+	"encoding/base64"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPrefetch(t *testing.T) {
+	pixel := "iVBORw0KGgoAAAANSUhEUgAAAAEAAAABCAYAAAAfFcSJAAAADUlEQVR42mNk+M9QDwADhgGAWjR9awAAAABJRU5ErkJggg=="
+	data, err := base64.StdEncoding.DecodeString(pixel)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var hits int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&hits, 1)
+		if r.URL.Path == "/broken.png" {
+			w.Write([]byte("not an image"))
+			return
+		}
+		w.Write(data)
+	}))
+
+	grid, err := NewGrid(3, 3, 16)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tiles := []Tile{}
+	for i := 0; i < 3; i++ {
+		ic := NewIcon(i, i, ts.URL+"/icon.png")
+		tiles = append(tiles, &ic)
+	}
+	broken := NewIcon(0, 2, ts.URL+"/broken.png")
+	tiles = append(tiles, &broken)
+
+	grid.Prefetch(2, tiles...)
+	ts.Close()
+
+	assert.Equal(t, int32(2), atomic.LoadInt32(&hits))
+
+	for _, el := range tiles[:3] {
+		assert.NoError(t, el.Plot(grid))
+	}
+	assert.Error(t, broken.Plot(grid))
+}
@@ -0,0 +1,140 @@
+package jumble
+
+import (
+	"image"
+	"math"
+
+	"github.com/disintegration/imaging"
+	"github.com/fogleman/gg"
+)
+
+// Shadow is a drop shadow cast by a tile.
+type Shadow struct {
+	// Offset is the shadow displacement in pixels
+	// (nil means the default offset (3, 3)).
+	Offset *gg.Point
+	// Blur is the blur radius in pixels (0 for a sharp shadow).
+	Blur  float64
+	Color string
+	// Disabled turns off the shadow (also the grid default one).
+	Disabled bool
+}
+
+// offset returns the shadow displacement.
+func (s *Shadow) offset() (float64, float64) {
+	if s.Offset == nil {
+		return 3, 3
+	}
+
+	return s.Offset.X, s.Offset.Y
+}
+
+// color returns the shadow color.
+func (s *Shadow) color() string {
+	if s.Color == "" {
+		return "#00000055"
+	}
+
+	return s.Color
+}
+
+// GridShadow sets the default shadow of
+// frames, label boxes, shapes and icons.
+func GridShadow(s *Shadow) func(*Grid) {
+	return func(g *Grid) {
+		g.shadow = s
+	}
+}
+
+// tileShadow returns the shadow to draw for a tile
+// (the grid default one when s is nil) or nil.
+func (g *Grid) tileShadow(s *Shadow) *Shadow {
+	if s == nil {
+		s = g.shadow
+	}
+
+	if s == nil || s.Disabled {
+		return nil
+	}
+
+	return s
+}
+
+// plotShadow draws the shadow of the shapes painted by draw inside
+// the box (x, y, w, h): these are painted (with the current
+// transformation of the grid context) on an alpha mask as large
+// as the box (plus the blur spread) that is moved, blurred and colored.
+func (g *Grid) plotShadow(s *Shadow, x, y, w, h float64, draw func(dc *gg.Context)) error {
+	col, err := parseHexColor(s.color())
+	if err != nil {
+		return err
+	}
+
+	dc := g.Context()
+	ox, oy := s.offset()
+
+	// the moved box in the image (plus the blur spread)
+	x0, y0, x1, y1 := math.Inf(1), math.Inf(1), math.Inf(-1), math.Inf(-1)
+	for _, p := range [][2]float64{{x, y}, {x + w, y}, {x, y + h}, {x + w, y + h}} {
+		px, py := dc.TransformPoint(p[0], p[1])
+		x0, y0 = math.Min(x0, px+ox), math.Min(y0, py+oy)
+		x1, y1 = math.Max(x1, px+ox), math.Max(y1, py+oy)
+	}
+
+	spread := math.Ceil(3*s.Blur) + 2
+	bounds := image.Rect(int(math.Floor(x0-spread)), int(math.Floor(y0-spread)),
+		int(math.Ceil(x1+spread)), int(math.Ceil(y1+spread)))
+	bounds = bounds.Intersect(image.Rect(0, 0, g.imageWidth, g.imageHeight))
+	if bounds.Empty() {
+		return nil
+	}
+
+	mask := gg.NewContext(bounds.Dx(), bounds.Dy())
+	mask.Translate(ox-float64(bounds.Min.X), oy-float64(bounds.Min.Y))
+	copyTransform(dc, mask)
+	mask.SetRGB(0, 0, 0)
+	draw(mask)
+
+	var blurred *image.NRGBA
+	if s.Blur > 0 {
+		blurred = imaging.Blur(mask.Image(), s.Blur)
+	} else {
+		blurred = imaging.Clone(mask.Image())
+	}
+
+	for i := 0; i+3 < len(blurred.Pix); i += 4 {
+		alpha := uint32(blurred.Pix[i+3]) * uint32(col.A) / 255
+		blurred.Pix[i], blurred.Pix[i+1], blurred.Pix[i+2] = col.R, col.G, col.B
+		blurred.Pix[i+3] = uint8(alpha)
+	}
+
+	dc.Push()
+	dc.Identity()
+	dc.DrawImage(blurred, bounds.Min.X, bounds.Min.Y)
+	dc.Pop()
+
+	return nil
+}
+
+// copyTransform applies the transformation of src to dst.
+func copyTransform(src, dst *gg.Context) {
+	e, f := src.TransformPoint(0, 0)
+	x1, y1 := src.TransformPoint(1, 0)
+	x2, y2 := src.TransformPoint(0, 1)
+	a, b, c, d := x1-e, y1-f, x2-e, y2-f
+
+	// decompose as translate * rotate * shear * scale
+	sx := math.Hypot(a, b)
+	if sx == 0 {
+		return
+	}
+	m := (a*c + b*d) / sx
+	sy := (a*d - b*c) / sx
+
+	dst.Translate(e, f)
+	dst.Rotate(math.Atan2(b, a))
+	if sy != 0 {
+		dst.Shear(m/sy, 0)
+	}
+	dst.Scale(sx, sy)
+}
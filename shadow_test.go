@@ -0,0 +1,61 @@
+package jumble
+
+import (
+	"testing"
+
+	"github.com/fogleman/gg"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestShadow(t *testing.T) {
+	def := &Shadow{Offset: &gg.Point{X: 10, Y: 10}, Blur: 4, Color: "#0000ff"}
+
+	tests := []struct {
+		name   string
+		shadow *Shadow
+		want   bool
+	}{
+		{"grid default", nil, true},
+		{"disabled", &Shadow{Disabled: true}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			grid, err := NewGrid(4, 4, 64, GridMargin(0), GridShadow(def))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			sh := NewShape(0, 0, ShapeRectangle, ShapeFill("#ff0000"), ShapeShadow(tt.shadow))
+			assert.NoError(t, sh.Plot(grid))
+
+			im := grid.Context().Image()
+			for _, x := range []int{62, 72} { // under the offset box and in the blur spread
+				r, g, b, _ := im.At(x, 40).RGBA()
+				shaded := b > r && r < 0xffff && g < 0xffff
+				assert.Equal(t, tt.want, shaded, "pixel (%d, 40)", x)
+			}
+
+			r, g, b, _ := im.At(32, 32).RGBA()
+			assert.Equal(t, []uint32{0xffff, 0, 0}, []uint32{r, g, b})
+		})
+	}
+}
+
+func TestShadowZeroOffset(t *testing.T) {
+	grid, err := NewGrid(4, 4, 64, GridMargin(0))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	glow := &Shadow{Offset: &gg.Point{}, Blur: 4, Color: "#0000ff"}
+	sh := NewShape(1, 1, ShapeRectangle, ShapeFill("#ff0000"), ShapeShadow(glow))
+	assert.NoError(t, sh.Plot(grid))
+
+	// the glow is centered around the shape
+	im := grid.Context().Image()
+	r1, _, _, _ := im.At(68, 96).RGBA()
+	r2, _, _, _ := im.At(123, 96).RGBA()
+	assert.True(t, r1 < 0xffff, "pixel (68, 96) not shaded")
+	assert.InDelta(t, r1, r2, 0x800)
+}
@@ -0,0 +1,429 @@
+package jumble
+
+import (
+	"fmt"
+	"math"
+	"sort"
+
+	"github.com/fogleman/gg"
+)
+
+// Shape kinds.
+const (
+	ShapeRectangle     = "rectangle"
+	ShapeEllipse       = "ellipse"
+	ShapeDiamond       = "diamond"
+	ShapeHexagon       = "hexagon"
+	ShapeCylinder      = "cylinder"
+	ShapeCloud         = "cloud"
+	ShapeParallelogram = "parallelogram"
+	ShapeDocument      = "document"
+	ShapeQueue         = "queue"
+	ShapePerson        = "person"
+)
+
+// ShapeFill sets the shape fill color
+// (empty for no fill)
+func ShapeFill(hex string) func(*Shape) {
+	return func(sh *Shape) {
+		sh.fill = hex
+	}
+}
+
+// ShapeStroke sets the shape stroke color and width
+// (empty color or zero width for no stroke)
+func ShapeStroke(hex string, width float64) func(*Shape) {
+	return func(sh *Shape) {
+		sh.stroke, sh.strokeWidth = hex, width
+	}
+}
+
+// ShapeText sets the text drawn inside the shape
+func ShapeText(text string) func(*Shape) {
+	return func(sh *Shape) {
+		sh.text = text
+	}
+}
+
+// ShapeTextColor sets the text color
+func ShapeTextColor(hex string) func(*Shape) {
+	return func(sh *Shape) {
+		sh.textColor = hex
+	}
+}
+
+// ShapeFontSize sets the text font size
+func ShapeFontSize(val float64) func(*Shape) {
+	return func(sh *Shape) {
+		sh.fontSize = val
+	}
+}
+
+// ShapeMarkup enables the inline markup of the text
+// (see LabelMarkup)
+func ShapeMarkup(val bool) func(*Shape) {
+	return func(sh *Shape) {
+		sh.markup = val
+	}
+}
+
+// ShapeRowSpan sets the number of rows covered by the shape
+func ShapeRowSpan(val int) func(*Shape) {
+	return func(sh *Shape) {
+		sh.rowSpan = val
+	}
+}
+
+// ShapeColSpan sets the number of columns covered by the shape
+func ShapeColSpan(val int) func(*Shape) {
+	return func(sh *Shape) {
+		sh.colSpan = val
+	}
+}
+
+// ShapeShadow sets the shape drop shadow
+func ShapeShadow(val *Shadow) func(*Shape) {
+	return func(sh *Shape) {
+		sh.shadow = val
+	}
+}
+
+// Shape is a diagram primitive (diamond, cylinder, ...).
+type Shape struct {
+	Row  int
+	Col  int
+	Kind string
+
+	fill        string
+	stroke      string
+	strokeWidth float64
+
+	text      string
+	textColor string
+	fontSize  float64
+	markup    bool
+
+	rowSpan int
+	colSpan int
+
+	shadow *Shadow
+}
+
+// NewShape returns a new shape of the specified kind
+func NewShape(row, col int, kind string, opts ...func(*Shape)) Shape {
+	res := Shape{
+		Row: row, Col: col,
+		Kind:        kind,
+		fill:        "#ffffff",
+		stroke:      "#000000",
+		strokeWidth: 1.5,
+		textColor:   "#000000",
+		rowSpan:     1,
+		colSpan:     1,
+	}
+
+	for _, opt := range opts {
+		opt(&res)
+	}
+
+	return res
+}
+
+// Location returns the grid position (row, col)
+func (sh *Shape) Location() (int, int) {
+	return sh.Row, sh.Col
+}
+
+// Move moves the shape by some rows and columns
+func (sh *Shape) Move(rows, cols int) {
+	sh.Row += rows
+	sh.Col += cols
+}
+
+// Span returns the number of rows and columns covered by the shape
+func (sh *Shape) Span() (int, int) {
+	return sh.rowSpan, sh.colSpan
+}
+
+// Plot draws the shape in a cell (or in the spanned cells)
+func (sh *Shape) Plot(g *Grid) error {
+	if sh.rowSpan < 1 || sh.colSpan < 1 {
+		return fmt.Errorf("invalid shape span: %d x %d", sh.rowSpan, sh.colSpan)
+	}
+
+	if err := g.VerifyInBounds(sh.Row, sh.Col); err != nil {
+		return err
+	}
+
+	if err := g.VerifyInBounds(sh.Row+sh.rowSpan-1, sh.Col+sh.colSpan-1); err != nil {
+		return err
+	}
+
+	// the shape box (the spanned cells with a small margin)
+	cs := g.CellSize()
+	m := 0.1 * cs
+	x := float64(sh.Col)*cs + m
+	y := float64(sh.Row)*cs + m
+	w := float64(sh.colSpan)*cs - 2*m
+	h := float64(sh.rowSpan)*cs - 2*m
+
+	path, ok := shapePaths[sh.Kind]
+	if !ok {
+		return fmt.Errorf("unknown shape: %s", sh.Kind)
+	}
+
+	if s := g.tileShadow(sh.shadow); s != nil {
+		lw := 0.5 * sh.strokeWidth
+		err := g.plotShadow(s, x-lw, y-lw, w+2*lw, h+2*lw, func(dc *gg.Context) {
+			path(dc, x, y, w, h)
+			if sh.fill != "" {
+				dc.Fill()
+				return
+			}
+			dc.SetLineWidth(sh.strokeWidth)
+			dc.Stroke()
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	dc := g.Context()
+	dc.Push()
+	defer dc.Pop()
+
+	details := path(dc, x, y, w, h)
+	if sh.fill != "" {
+		dc.SetHexColor(sh.fill)
+		dc.FillPreserve()
+	}
+
+	stroke := sh.stroke != "" && sh.strokeWidth > 0
+	if stroke {
+		dc.SetHexColor(sh.stroke)
+		dc.SetLineWidth(sh.strokeWidth)
+		dc.Stroke()
+
+		if details != nil {
+			details()
+			dc.Stroke()
+		}
+	}
+	dc.ClearPath()
+
+	if sh.text == "" {
+		return nil
+	}
+
+	tx, ty, tw, th := shapeTextBox(sh.Kind, x, y, w, h)
+
+	return sh.plotText(g, tx, ty, tw, th)
+}
+
+// plotText draws the text centered in the box,
+// wrapping and ellipsizing it to fit.
+func (sh *Shape) plotText(g *Grid, x, y, w, h float64) error {
+	size := sh.fontSize
+	if size <= 0 {
+		size = 0.25 * g.CellSize()
+	}
+
+	base := textStyle{color: sh.textColor, size: size}
+	block, err := g.layoutText(parseMarkup(sh.text, base, sh.markup), base, w, 1)
+	if err != nil {
+		return err
+	}
+
+	if block.width > w || block.height > h {
+		block.truncate(w, h)
+	}
+
+	dc := g.Context()
+	dc.Push()
+	defer dc.Pop()
+
+	dc.SetHexColor(sh.textColor)
+	block.draw(dc, x+0.5*(w-block.width), y+0.5*(h-block.height), 0.5)
+
+	return nil
+}
+
+// shapePath adds the outline of a shape with the bounding box
+// at (x, y) and size (w, h) to the context; it can return
+// a function adding the inner details (stroked only).
+type shapePath func(dc *gg.Context, x, y, w, h float64) func()
+
+// ShapeKinds returns the names of all the shapes.
+func ShapeKinds() []string {
+	res := make([]string, 0, len(shapePaths))
+	for kind := range shapePaths {
+		res = append(res, kind)
+	}
+	sort.Strings(res)
+
+	return res
+}
+
+var shapePaths = map[string]shapePath{
+	ShapeRectangle: func(dc *gg.Context, x, y, w, h float64) func() {
+		dc.DrawRectangle(x, y, w, h)
+		return nil
+	},
+
+	ShapeEllipse: func(dc *gg.Context, x, y, w, h float64) func() {
+		dc.DrawEllipse(x+0.5*w, y+0.5*h, 0.5*w, 0.5*h)
+		return nil
+	},
+
+	ShapeDiamond: func(dc *gg.Context, x, y, w, h float64) func() {
+		polygon(dc, x+0.5*w, y, x+w, y+0.5*h, x+0.5*w, y+h, x, y+0.5*h)
+		return nil
+	},
+
+	ShapeHexagon: func(dc *gg.Context, x, y, w, h float64) func() {
+		k := math.Min(0.25*w, 0.5*h)
+		polygon(dc, x+k, y, x+w-k, y, x+w, y+0.5*h, x+w-k, y+h, x+k, y+h, x, y+0.5*h)
+		return nil
+	},
+
+	ShapeParallelogram: func(dc *gg.Context, x, y, w, h float64) func() {
+		k := math.Min(0.2*w, 0.5*h)
+		polygon(dc, x+k, y, x+w, y, x+w-k, y+h, x, y+h)
+		return nil
+	},
+
+	ShapeCylinder: func(dc *gg.Context, x, y, w, h float64) func() {
+		rx, ry := 0.5*w, math.Min(0.12*h, 0.25*w)
+		cx := x + rx
+
+		dc.MoveTo(x, y+ry)
+		dc.LineTo(x, y+h-ry)
+		dc.DrawEllipticalArc(cx, y+h-ry, rx, ry, math.Pi, 0)
+		dc.LineTo(x+w, y+ry)
+		dc.DrawEllipticalArc(cx, y+ry, rx, ry, 0, -math.Pi)
+		dc.ClosePath()
+
+		// the front side of the top ellipse
+		return func() {
+			dc.NewSubPath()
+			dc.DrawEllipticalArc(cx, y+ry, rx, ry, math.Pi, 0)
+		}
+	},
+
+	ShapeQueue: func(dc *gg.Context, x, y, w, h float64) func() {
+		rx, ry := math.Min(0.12*w, 0.25*h), 0.5*h
+		cy := y + ry
+
+		dc.MoveTo(x+rx, y)
+		dc.LineTo(x+w-rx, y)
+		dc.DrawEllipticalArc(x+w-rx, cy, rx, ry, -0.5*math.Pi, 0.5*math.Pi)
+		dc.LineTo(x+rx, y+h)
+		dc.DrawEllipticalArc(x+rx, cy, rx, ry, 0.5*math.Pi, 1.5*math.Pi)
+		dc.ClosePath()
+
+		// the front side of the right ellipse
+		return func() {
+			dc.NewSubPath()
+			dc.DrawEllipticalArc(x+w-rx, cy, rx, ry, -0.5*math.Pi, -1.5*math.Pi)
+		}
+	},
+
+	ShapeDocument: func(dc *gg.Context, x, y, w, h float64) func() {
+		a := 0.1 * h
+
+		dc.MoveTo(x, y)
+		dc.LineTo(x+w, y)
+		dc.LineTo(x+w, y+h-a)
+		dc.CubicTo(x+0.75*w, y+h-3*a, x+0.25*w, y+h+a, x, y+h-a)
+		dc.ClosePath()
+
+		return nil
+	},
+
+	ShapeCloud: func(dc *gg.Context, x, y, w, h float64) func() {
+		const bumps = 8
+
+		// the bumps bulge about 1.22 times the base radius
+		cx, cy := x+0.5*w, y+0.5*h
+		rx, ry := 0.5*w/1.22, 0.5*h/1.22
+
+		at := func(a, k float64) (float64, float64) {
+			return cx + k*rx*math.Cos(a), cy + k*ry*math.Sin(a)
+		}
+
+		step := 2 * math.Pi / bumps
+		dc.MoveTo(at(0, 1))
+		for i := 0; i < bumps; i++ {
+			a1, a2 := float64(i)*step, float64(i+1)*step
+			x1, y1 := at(a1+0.1*step, 1.3)
+			x2, y2 := at(a2-0.1*step, 1.3)
+			x3, y3 := at(a2, 1)
+			dc.CubicTo(x1, y1, x2, y2, x3, y3)
+		}
+		dc.ClosePath()
+
+		return nil
+	},
+
+	ShapePerson: func(dc *gg.Context, x, y, w, h float64) func() {
+		cx := x + 0.5*w
+		r := 0.2 * math.Min(w, h)
+		dc.DrawCircle(cx, y+r, r)
+
+		// the body with rounded shoulders
+		bw := math.Min(w, 0.8*h)
+		by := y + 2.2*r
+		k := 0.4 * bw
+
+		dc.NewSubPath()
+		dc.MoveTo(cx-0.5*bw, y+h)
+		dc.LineTo(cx-0.5*bw, by+k)
+		dc.QuadraticTo(cx-0.5*bw, by, cx-0.5*bw+k, by)
+		dc.LineTo(cx+0.5*bw-k, by)
+		dc.QuadraticTo(cx+0.5*bw, by, cx+0.5*bw, by+k)
+		dc.LineTo(cx+0.5*bw, y+h)
+		dc.ClosePath()
+
+		return nil
+	},
+}
+
+// shapeTextBox returns the area available for the text.
+func shapeTextBox(kind string, x, y, w, h float64) (float64, float64, float64, float64) {
+	switch kind {
+	case ShapeDiamond:
+		return x + 0.25*w, y + 0.25*h, 0.5 * w, 0.5 * h
+	case ShapeEllipse:
+		return x + 0.15*w, y + 0.15*h, 0.7 * w, 0.7 * h
+	case ShapeHexagon:
+		k := math.Min(0.25*w, 0.5*h)
+		return x + 0.5*k, y, w - k, h
+	case ShapeParallelogram:
+		k := math.Min(0.2*w, 0.5*h)
+		return x + 0.5*k, y, w - k, h
+	case ShapeCylinder:
+		ry := math.Min(0.12*h, 0.25*w)
+		return x, y + 2*ry, w, h - 3*ry
+	case ShapeQueue:
+		rx := math.Min(0.12*w, 0.25*h)
+		return x + rx, y, w - 3*rx, h
+	case ShapeDocument:
+		return x, y, w, 0.85 * h
+	case ShapeCloud:
+		return x + 0.2*w, y + 0.2*h, 0.6 * w, 0.6 * h
+	case ShapePerson:
+		r := 0.2 * math.Min(w, h)
+		return x, y + 2.6*r, w, h - 2.6*r
+	}
+
+	return x, y, w, h
+}
+
+// polygon adds a closed polygon (x1, y1, x2, y2, ...) to the context.
+func polygon(dc *gg.Context, coords ...float64) {
+	dc.MoveTo(coords[0], coords[1])
+	for i := 2; i+1 < len(coords); i += 2 {
+		dc.LineTo(coords[i], coords[i+1])
+	}
+	dc.ClosePath()
+}
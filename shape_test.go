@@ -0,0 +1,43 @@
+package jumble
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestShapePlot(t *testing.T) {
+	grid, err := NewGrid(4, 4, 64, GridMargin(0))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	kinds := []string{
+		ShapeRectangle, ShapeEllipse, ShapeDiamond, ShapeHexagon, ShapeCylinder,
+		ShapeCloud, ShapeParallelogram, ShapeDocument, ShapeQueue, ShapePerson,
+	}
+
+	for _, kind := range kinds {
+		t.Run(kind, func(t *testing.T) {
+			sh := NewShape(0, 0, kind, ShapeText(kind), ShapeRowSpan(2), ShapeColSpan(2))
+			assert.NoError(t, sh.Plot(grid))
+		})
+	}
+
+	sh := NewShape(2, 2, ShapeDiamond, ShapeFill("#ff0000"), ShapeStroke("", 0))
+	assert.NoError(t, sh.Plot(grid))
+
+	img := grid.Context().Image()
+
+	// the diamond center is filled, its corners are not
+	r, g, b, _ := img.At(160, 160).RGBA()
+	assert.Equal(t, []uint32{0xffff, 0, 0}, []uint32{r, g, b})
+	r, g, b, _ = img.At(135, 135).RGBA()
+	assert.Equal(t, []uint32{0xffff, 0xffff, 0xffff}, []uint32{r, g, b})
+
+	sh = NewShape(0, 0, "star")
+	assert.Error(t, sh.Plot(grid))
+
+	sh = NewShape(3, 3, ShapeCloud, ShapeColSpan(2))
+	assert.Error(t, sh.Plot(grid))
+}
@@ -0,0 +1,621 @@
+package jumble
+
+import (
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/fogleman/gg"
+	"golang.org/x/image/font"
+)
+
+// textStyle is the style of a run of text.
+type textStyle struct {
+	family string
+	bold   bool
+	italic bool
+	mono   bool
+	color  string
+	size   float64
+}
+
+// textRun is a piece of text drawn with the same style.
+type textRun struct {
+	text  string
+	style textStyle
+	face  font.Face
+	width float64
+}
+
+// textLine is a laid out line of text.
+type textLine struct {
+	runs   []textRun
+	width  float64
+	height float64
+}
+
+// textBlock is a laid out multi-line text.
+type textBlock struct {
+	lines   []textLine
+	spacing float64
+	size    float64
+	width   float64
+	height  float64
+}
+
+// parseMarkup splits the text in lines of styled runs.
+//
+// When markup is enabled the text supports:
+//
+//	**bold**, *italic*, `monospace`
+//	[text]{color=#888888 size=0.8}  (size is relative)
+//
+// and a backslash escapes the next character.
+func parseMarkup(text string, base textStyle, markup bool) [][]textRun {
+	var runs []textRun
+	if markup {
+		runs = (&markupParser{src: []rune(text), base: base}).parse()
+	} else {
+		runs = []textRun{{text: text, style: base}}
+	}
+
+	res := [][]textRun{nil}
+	for _, run := range runs {
+		for i, el := range strings.Split(run.text, "\n") {
+			if i > 0 {
+				res = append(res, nil)
+			}
+			if el != "" {
+				n := len(res) - 1
+				res[n] = append(res[n], textRun{text: el, style: run.style})
+			}
+		}
+	}
+
+	return res
+}
+
+// markupParser turns the label markup into styled runs.
+type markupParser struct {
+	src  []rune
+	base textStyle
+	runs []textRun
+	buf  []rune
+}
+
+// span is an open [text]{attrs} span.
+type span struct {
+	closeAt  int
+	resumeAt int
+	prev     textStyle
+}
+
+func (p *markupParser) parse() []textRun {
+	style := p.base
+	var spans []span
+
+	for i := 0; i < len(p.src); {
+		if n := len(spans); n > 0 && i == spans[n-1].closeAt {
+			p.flush(style)
+			style = spans[n-1].prev
+			i = spans[n-1].resumeAt
+			spans = spans[:n-1]
+			continue
+		}
+
+		c := p.src[i]
+		switch {
+		case c == '\\' && i+1 < len(p.src):
+			p.buf = append(p.buf, p.src[i+1])
+			i += 2
+			continue
+
+		case c == '*' && p.hasPrefix(i, "**") && (style.bold || p.index(i+2, "**") >= 0):
+			p.flush(style)
+			style.bold = !style.bold
+			i += 2
+			continue
+
+		case c == '*' && (style.italic || p.index(i+1, "*") >= 0):
+			p.flush(style)
+			style.italic = !style.italic
+			i++
+			continue
+
+		case c == '`' && (style.mono || p.index(i+1, "`") >= 0):
+			p.flush(style)
+			style.mono = !style.mono
+			i++
+			continue
+
+		case c == '[':
+			if sp, attrs, ok := p.span(i); ok {
+				p.flush(style)
+				sp.prev = style
+				style = applyAttrs(style, attrs, p.base.size)
+				spans = append(spans, sp)
+				i++
+				continue
+			}
+		}
+
+		p.buf = append(p.buf, c)
+		i++
+	}
+
+	p.flush(style)
+
+	return p.runs
+}
+
+// flush closes the current run.
+func (p *markupParser) flush(style textStyle) {
+	if len(p.buf) == 0 {
+		return
+	}
+
+	p.runs = append(p.runs, textRun{text: string(p.buf), style: style})
+	p.buf = nil
+}
+
+// span looks for the `]{attrs}` closing the span opened at i.
+func (p *markupParser) span(i int) (span, string, bool) {
+	depth := 0
+	for j := i + 1; j < len(p.src); j++ {
+		switch p.src[j] {
+		case '\\':
+			j++
+		case '[':
+			depth++
+		case ']':
+			if depth > 0 {
+				depth--
+				continue
+			}
+			if j+1 >= len(p.src) || p.src[j+1] != '{' {
+				return span{}, "", false
+			}
+			k := p.index(j+2, "}")
+			if k < 0 {
+				return span{}, "", false
+			}
+			return span{closeAt: j, resumeAt: k + 1}, string(p.src[j+2 : k]), true
+		}
+	}
+
+	return span{}, "", false
+}
+
+func (p *markupParser) hasPrefix(i int, s string) bool {
+	return strings.HasPrefix(string(p.src[i:]), s)
+}
+
+// index returns the position of s starting
+// from i, ignoring the escaped characters (or -1).
+func (p *markupParser) index(i int, s string) int {
+	for j := i; j < len(p.src); j++ {
+		if p.src[j] == '\\' {
+			j++
+			continue
+		}
+		if p.hasPrefix(j, s) {
+			return j
+		}
+	}
+
+	return -1
+}
+
+// applyAttrs applies the span attributes (color, size) to the style.
+func applyAttrs(style textStyle, attrs string, baseSize float64) textStyle {
+	fields := strings.FieldsFunc(attrs, func(r rune) bool {
+		return r == ',' || unicode.IsSpace(r)
+	})
+
+	for _, el := range fields {
+		parts := strings.SplitN(el, "=", 2)
+		if len(parts) != 2 {
+			continue
+		}
+
+		switch key, val := parts[0], parts[1]; key {
+		case "color":
+			style.color = val
+		case "size":
+			if f, err := strconv.ParseFloat(val, 64); err == nil && f > 0 {
+				style.size = f * baseSize
+			}
+		}
+	}
+
+	return style
+}
+
+// layoutText wraps the lines to the max width (if positive)
+// and measures them; spacing is the lines spacing multiplier
+// and base is the style of the empty lines.
+func (g *Grid) layoutText(lines [][]textRun, base textStyle, maxWidth, spacing float64) (textBlock, error) {
+	res := textBlock{spacing: spacing}
+
+	for _, runs := range lines {
+		runs, err := g.fallbackRuns(runs)
+		if err != nil {
+			return textBlock{}, err
+		}
+
+		wrapped := [][]textRun{runs}
+		if maxWidth > 0 && len(runs) > 0 {
+			var err error
+			if wrapped, err = g.wrapRuns(runs, maxWidth); err != nil {
+				return textBlock{}, err
+			}
+			if len(wrapped) == 0 {
+				wrapped = [][]textRun{runs}
+			}
+		}
+
+		for _, el := range wrapped {
+			line, err := g.measureLine(el, base)
+			if err != nil {
+				return textBlock{}, err
+			}
+			res.lines = append(res.lines, line)
+		}
+	}
+
+	res.measure()
+
+	return res, nil
+}
+
+// measure computes the block size from the lines.
+func (b *textBlock) measure() {
+	b.width, b.height = 0, 0
+
+	// sync h formula with gg.MeasureMultilineString
+	for i, line := range b.lines {
+		if line.width > b.width {
+			b.width = line.width
+		}
+
+		b.height += line.height * b.spacing
+		if i == len(b.lines)-1 {
+			b.height -= (b.spacing - 1) * line.height
+		}
+	}
+}
+
+// truncate drops the lines overflowing the height
+// and shortens the ones overflowing the width,
+// marking the cuts with an ellipsis.
+func (b *textBlock) truncate(w, h float64) {
+	n, y := 0, 0.0
+	for i, line := range b.lines {
+		if i > 0 && y+line.height > h {
+			break
+		}
+		y += line.height * b.spacing
+		n = i + 1
+	}
+
+	cut := n < len(b.lines)
+	b.lines = b.lines[:n]
+
+	for i := range b.lines {
+		if b.lines[i].width > w || (cut && i == n-1) {
+			b.lines[i].ellipsize(w)
+		}
+	}
+
+	b.measure()
+}
+
+// ellipsis marks the truncated text.
+const ellipsis = "…"
+
+// ellipsize shortens the line to the
+// width and appends the ellipsis.
+func (l *textLine) ellipsize(w float64) {
+	for n := len(l.runs); n > 0; n = len(l.runs) {
+		last := &l.runs[n-1]
+		rest := l.width - last.width
+
+		text := strings.TrimRightFunc(last.text, unicode.IsSpace)
+		if rest+measureString(last.face, text+ellipsis) <= w || (n == 1 && text == "") {
+			last.text = text + ellipsis
+			break
+		}
+
+		if text == "" {
+			l.runs, l.width = l.runs[:n-1], rest
+			continue
+		}
+
+		rs := []rune(text)
+		last.text = string(rs[:len(rs)-1])
+		last.width = measureString(last.face, last.text)
+		l.width = rest + last.width
+	}
+
+	l.width = 0
+	for i := range l.runs {
+		l.runs[i].width = measureString(l.runs[i].face, l.runs[i].text)
+		l.width += l.runs[i].width
+	}
+}
+
+// measureLine resolves the font faces and measures the runs;
+// empty lines take the height of the base style.
+func (g *Grid) measureLine(runs []textRun, base textStyle) (textLine, error) {
+	var res textLine
+
+	for _, run := range mergeRuns(runs) {
+		face, err := g.runFace(run.style)
+		if err != nil {
+			return textLine{}, err
+		}
+
+		run.face = face
+		run.width = measureString(face, run.text)
+
+		res.width += run.width
+		if h := float64(face.Metrics().Height) / 64; h > res.height {
+			res.height = h
+		}
+		res.runs = append(res.runs, run)
+	}
+
+	if len(res.runs) == 0 {
+		face, err := g.runFace(base)
+		if err != nil {
+			return textLine{}, err
+		}
+		res.height = float64(face.Metrics().Height) / 64
+	}
+
+	return res, nil
+}
+
+// runFace returns the font face of the run style.
+func (g *Grid) runFace(style textStyle) (font.Face, error) {
+	family := runFamily(style)
+
+	size := style.size
+	if size <= 0 {
+		size = 0.3 * g.CellSize()
+	}
+
+	return g.fontFace(family, style.bold, style.italic, size)
+}
+
+// runFamily returns the font family of the run style.
+func runFamily(style textStyle) string {
+	if style.mono {
+		return "mono"
+	}
+
+	return style.family
+}
+
+// fallbackRuns splits the runs so that each character is drawn
+// with the first font having its glyph: the run font, then the
+// grid fallback fonts (in order).
+func (g *Grid) fallbackRuns(runs []textRun) ([]textRun, error) {
+	if len(g.fallbackFonts) == 0 {
+		return runs, nil
+	}
+
+	var res []textRun
+	for _, run := range runs {
+		chain := make([]*Font, 0, len(g.fallbackFonts)+1)
+		for _, name := range append([]string{runFamily(run.style)}, g.fallbackFonts...) {
+			f, err := g.font(name, run.style.bold, run.style.italic)
+			if err != nil {
+				return nil, err
+			}
+			chain = append(chain, f)
+		}
+
+		res = append(res, splitRunes(run, func(r rune) string {
+			if unicode.IsSpace(r) || chain[0].HasGlyph(r) {
+				return ""
+			}
+			for i, f := range chain[1:] {
+				if f.HasGlyph(r) {
+					return g.fallbackFonts[i]
+				}
+			}
+			return ""
+		})...)
+	}
+
+	return res, nil
+}
+
+// splitRunes splits the run where the font family returned
+// by familyOf changes ("" keeps the run font).
+func splitRunes(run textRun, familyOf func(r rune) string) []textRun {
+	var res []textRun
+	var cur []rune
+	curFamily := ""
+
+	flush := func() {
+		if len(cur) == 0 {
+			return
+		}
+
+		style := run.style
+		if curFamily != "" {
+			style.family, style.mono = curFamily, false
+		}
+		res = append(res, textRun{text: string(cur), style: style})
+		cur = nil
+	}
+
+	for _, r := range run.text {
+		if family := familyOf(r); family != curFamily {
+			flush()
+			curFamily = family
+		}
+		cur = append(cur, r)
+	}
+	flush()
+
+	return res
+}
+
+// piece is a word or a space of a styled run.
+type piece struct {
+	text  string
+	style textStyle
+	space bool
+}
+
+// wrapRuns word-wraps the runs to the specified width
+// (same algorithm of gg.WordWrap, but style aware).
+func (g *Grid) wrapRuns(runs []textRun, width float64) ([][]textRun, error) {
+	// split in words, each followed by its spaces
+	type word struct {
+		text []piece
+		sep  []piece
+	}
+
+	var words []word
+	for _, run := range runs {
+		for _, el := range splitOnSpace(run.text) {
+			pc := piece{text: el, style: run.style, space: strings.TrimSpace(el) == ""}
+
+			n := len(words)
+			switch {
+			case pc.space && n > 0:
+				words[n-1].sep = append(words[n-1].sep, pc)
+			case pc.space:
+				words = append(words, word{sep: []piece{pc}})
+			case n > 0 && len(words[n-1].sep) == 0:
+				words[n-1].text = append(words[n-1].text, pc)
+			default:
+				words = append(words, word{text: []piece{pc}})
+			}
+		}
+	}
+
+	var res [][]textRun
+	var cur []piece
+	for _, w := range words {
+		cand := append(append([]piece{}, cur...), w.text...)
+		cw, err := g.piecesWidth(cand)
+		if err != nil {
+			return nil, err
+		}
+
+		if cw > width {
+			if len(trimPieces(cur)) == 0 {
+				res = append(res, piecesToRuns(w.text))
+				cur = nil
+				continue
+			}
+			res = append(res, piecesToRuns(trimPieces(cur)))
+			cand = append([]piece{}, w.text...)
+		}
+
+		cur = append(cand, w.sep...)
+	}
+
+	if len(trimPieces(cur)) > 0 {
+		res = append(res, piecesToRuns(trimPieces(cur)))
+	}
+
+	return res, nil
+}
+
+// piecesWidth measures the pieces as merged runs.
+func (g *Grid) piecesWidth(pieces []piece) (float64, error) {
+	res := 0.0
+	for _, run := range piecesToRuns(pieces) {
+		face, err := g.runFace(run.style)
+		if err != nil {
+			return 0, err
+		}
+		res += measureString(face, run.text)
+	}
+
+	return res, nil
+}
+
+// draw draws the text block with the top left corner at (x, y);
+// tx is the lines alignment (0: left, 0.5: center, 1: right).
+func (b *textBlock) draw(dc *gg.Context, x, y, tx float64) {
+	for _, line := range b.lines {
+		lx := x + tx*(b.width-line.width)
+		for _, run := range line.runs {
+			dc.SetFontFace(run.face)
+			if run.style.color != "" {
+				dc.SetHexColor(run.style.color)
+			}
+			dc.DrawString(run.text, lx, y+line.height)
+			lx += run.width
+		}
+		y += line.height * b.spacing
+	}
+}
+
+// measureString returns the width of the string
+// (truncated like gg.Context.MeasureString).
+func measureString(face font.Face, s string) float64 {
+	return float64(font.MeasureString(face, s) >> 6)
+}
+
+// mergeRuns joins the adjacent runs with the same style.
+func mergeRuns(runs []textRun) []textRun {
+	var res []textRun
+	for _, run := range runs {
+		if n := len(res); n > 0 && res[n-1].style == run.style {
+			res[n-1].text += run.text
+			continue
+		}
+		res = append(res, textRun{text: run.text, style: run.style})
+	}
+
+	return res
+}
+
+func piecesToRuns(pieces []piece) []textRun {
+	res := make([]textRun, 0, len(pieces))
+	for _, pc := range pieces {
+		res = append(res, textRun{text: pc.text, style: pc.style})
+	}
+
+	return mergeRuns(res)
+}
+
+// trimPieces removes the leading and trailing spaces.
+func trimPieces(pieces []piece) []piece {
+	for len(pieces) > 0 && pieces[0].space {
+		pieces = pieces[1:]
+	}
+	for len(pieces) > 0 && pieces[len(pieces)-1].space {
+		pieces = pieces[:len(pieces)-1]
+	}
+
+	return pieces
+}
+
+// splitOnSpace splits the string in words and spaces.
+func splitOnSpace(s string) []string {
+	var res []string
+	pi, ps := 0, false
+	for i, c := range s {
+		sp := unicode.IsSpace(c)
+		if sp != ps && i > 0 {
+			res = append(res, s[pi:i])
+			pi = i
+		}
+		ps = sp
+	}
+
+	if pi < len(s) {
+		res = append(res, s[pi:])
+	}
+
+	return res
+}